@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxUIDLength is the longest user ID the API accepts.
+const maxUIDLength = 128
+
+// maxCustomClaimsSize is the most bytes custom user
+// claims may take up, serialized as JSON.
+const maxCustomClaimsSize = 1000
+
+// minPasswordLength is the shortest password the API accepts.
+const minPasswordLength = 6
+
+// AdminClient manages the users of a project through the
+// Identity Toolkit API.
+//
+// For more information, see the documentation at:
+// https://firebase.google.com/docs/auth/admin/manage-users
+type AdminClient struct {
+	toolkit *toolkit
+	backoff Backoff // for retrying transient errors
+}
+
+// adminBackoff is the Backoff used by AdminClient
+// to retry transient errors.
+var adminBackoff = Backoff{
+	Base:   500 * time.Millisecond,
+	Max:    10 * time.Second,
+	Jitter: 0.2,
+}
+
+// NewAdminClient returns an AdminClient for the users of the given
+// project. client must be authorized to manage them, such as one
+// created with golang.org/x/oauth2/google using service account
+// credentials. If client is nil, http.DefaultClient is used.
+func NewAdminClient(projectID string, client *http.Client) *AdminClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &AdminClient{
+		toolkit: newToolkit(projectID, client),
+		backoff: adminBackoff,
+	}
+}
+
+// GetUser returns the user with the given ID, or ErrUserNotFound.
+func (c *AdminClient) GetUser(ctx context.Context, uid string) (*UserRecord, error) {
+	if err := validateUID(uid); err != nil {
+		return nil, err
+	}
+	return c.lookup(ctx, map[string]interface{}{"localId": []string{uid}})
+}
+
+// GetUserByEmail returns the user with the given email address,
+// or ErrUserNotFound.
+func (c *AdminClient) GetUserByEmail(ctx context.Context, email string) (*UserRecord, error) {
+	if email == "" {
+		return nil, errors.New("auth: empty email")
+	}
+	return c.lookup(ctx, map[string]interface{}{"email": []string{email}})
+}
+
+// GetUserByPhoneNumber returns the user with the given phone number,
+// in E.164 format, or ErrUserNotFound.
+func (c *AdminClient) GetUserByPhoneNumber(ctx context.Context, phone string) (*UserRecord, error) {
+	if phone == "" {
+		return nil, errors.New("auth: empty phone number")
+	}
+	return c.lookup(ctx, map[string]interface{}{"phoneNumber": []string{phone}})
+}
+
+// lookup returns the single user matching req.
+func (c *AdminClient) lookup(ctx context.Context, req map[string]interface{}) (*UserRecord, error) {
+	var resp struct {
+		Users []userResponse `json:"users"`
+	}
+	if err := c.toolkit.post(ctx, "/accounts:lookup", req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Users) == 0 {
+		return nil, ErrUserNotFound
+	}
+	return resp.Users[0].record()
+}
+
+// CreateUser creates a user and returns it. If user does not set
+// a UID, the server generates one.
+func (c *AdminClient) CreateUser(ctx context.Context, user *UserToCreate) (*UserRecord, error) {
+	if user == nil {
+		user = new(UserToCreate)
+	}
+	if err := user.validate(); err != nil {
+		return nil, err
+	}
+	var resp struct {
+		LocalID string `json:"localId"`
+	}
+	if err := c.toolkit.post(ctx, "/accounts", user.params, &resp); err != nil {
+		return nil, err
+	}
+	if resp.LocalID == "" {
+		return nil, errors.New("auth: server returned an empty user ID")
+	}
+	return c.GetUser(ctx, resp.LocalID)
+}
+
+// UpdateUser updates the user with the given ID and returns it.
+func (c *AdminClient) UpdateUser(ctx context.Context, uid string, user *UserToUpdate) (*UserRecord, error) {
+	if err := validateUID(uid); err != nil {
+		return nil, err
+	}
+	if user == nil || len(user.params) == 0 {
+		return nil, errors.New("auth: no fields to update")
+	}
+	req, err := user.request(uid)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.toolkit.post(ctx, "/accounts:update", req, nil); err != nil {
+		return nil, err
+	}
+	return c.GetUser(ctx, uid)
+}
+
+// SetCustomUserClaims sets the custom claims of the user with the
+// given ID, replacing any previous ones. The claims are added to the
+// user's ID tokens when they are next refreshed, where they are
+// available from User.CustomClaims. A nil or empty map removes
+// the custom claims.
+//
+// The claims must not use reserved names, such as "sub" or "firebase",
+// and must not exceed 1000 bytes when serialized as JSON.
+func (c *AdminClient) SetCustomUserClaims(ctx context.Context, uid string, claims map[string]interface{}) error {
+	if err := validateUID(uid); err != nil {
+		return err
+	}
+	attrs, err := marshalCustomClaims(claims)
+	if err != nil {
+		return err
+	}
+	req := struct {
+		LocalID          string `json:"localId"`
+		CustomAttributes string `json:"customAttributes"`
+	}{uid, attrs}
+	return c.toolkit.post(ctx, "/accounts:update", req, nil)
+}
+
+// DeleteUser deletes the user with the given ID.
+func (c *AdminClient) DeleteUser(ctx context.Context, uid string) error {
+	if err := validateUID(uid); err != nil {
+		return err
+	}
+	req := struct {
+		LocalID string `json:"localId"`
+	}{uid}
+	return c.toolkit.post(ctx, "/accounts:delete", req, nil)
+}
+
+// marshalCustomClaims validates custom user claims and serializes
+// them as the API expects.
+func marshalCustomClaims(claims map[string]interface{}) (string, error) {
+	if len(claims) == 0 {
+		return "{}", nil
+	}
+	for key := range claims {
+		if reservedClaims[key] {
+			return "", fmt.Errorf("auth: custom claim %q is reserved", key)
+		}
+	}
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("auth: cannot marshal custom claims: %v", err)
+	}
+	if len(data) > maxCustomClaimsSize {
+		return "", fmt.Errorf("auth: custom claims are %d bytes, more than the maximum of %d", len(data), maxCustomClaimsSize)
+	}
+	return string(data), nil
+}
+
+func validateUID(uid string) error {
+	if uid == "" {
+		return errors.New("auth: empty user ID")
+	}
+	if len(uid) > maxUIDLength {
+		return fmt.Errorf("auth: user ID longer than %d characters", maxUIDLength)
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeToolkit is an in-memory Identity Toolkit server
+// for the user management endpoints.
+type fakeToolkit struct {
+	t         *testing.T
+	projectID string
+
+	mu        sync.Mutex
+	users     map[string]map[string]interface{} // by localId
+	nextID    int
+	failures  int // number of requests to fail with HTTP 503
+	failAfter int // if positive, fail requests after this many with HTTP 503
+	requests  int
+
+	lastImport map[string]interface{} // last accounts:batchCreate request
+}
+
+func newFakeToolkit(t *testing.T, projectID string) *fakeToolkit {
+	return &fakeToolkit{t: t, projectID: projectID, users: make(map[string]map[string]interface{})}
+}
+
+// newClient serves f until the test ends and returns an AdminClient
+// that sends its requests there.
+func (f *fakeToolkit) newClient() *AdminClient {
+	serv := httptest.NewServer(f)
+	f.t.Cleanup(serv.Close)
+	c := NewAdminClient(f.projectID, nil)
+	c.toolkit.baseURL = serv.URL
+	return c
+}
+
+func (f *fakeToolkit) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	prefix := "/projects/" + f.projectID
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		http.NotFound(w, req)
+		return
+	}
+	body := make(map[string]interface{})
+	if req.Method == "POST" {
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			f.t.Errorf("could not decode request: %v", err)
+		}
+	}
+	for k, v := range req.URL.Query() {
+		body[k] = v[0]
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	if f.failAfter > 0 && f.requests > f.failAfter {
+		f.failures = 1
+	}
+	if f.failures > 0 {
+		f.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": {"code": 503, "message": "UNAVAILABLE"}}`))
+		return
+	}
+	resp, code := f.handle(strings.TrimPrefix(req.URL.Path, prefix), body)
+	if code != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]interface{}{"code": 400, "message": code},
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handle serves a request to path, returning the response
+// or an error code.
+func (f *fakeToolkit) handle(path string, body map[string]interface{}) (interface{}, string) {
+	switch path {
+	case "/accounts:lookup":
+		var users []map[string]interface{}
+		for _, field := range []string{"localId", "email", "phoneNumber"} {
+			values, _ := body[field].([]interface{})
+			for _, value := range values {
+				for _, u := range f.users {
+					if u[field] == value {
+						users = append(users, u)
+					}
+				}
+			}
+		}
+		feds, _ := body["federatedUserId"].([]interface{})
+		for _, fed := range feds {
+			fed := fed.(map[string]interface{})
+			for _, u := range f.users {
+				infos, _ := u["providerUserInfo"].([]interface{})
+				for _, info := range infos {
+					info := info.(map[string]interface{})
+					if info["providerId"] == fed["providerId"] && info["rawId"] == fed["rawId"] {
+						users = append(users, u)
+					}
+				}
+			}
+		}
+		return map[string]interface{}{"users": users}, ""
+
+	case "/accounts":
+		uid, _ := body["localId"].(string)
+		if uid == "" {
+			f.nextID++
+			uid = "generated" + string(rune('0'+f.nextID))
+		}
+		if _, ok := f.users[uid]; ok {
+			return nil, "DUPLICATE_LOCAL_ID"
+		}
+		u := map[string]interface{}{"localId": uid, "createdAt": "1500000000000"}
+		for k, v := range body {
+			if k != "password" {
+				u[k] = v
+			}
+		}
+		f.users[uid] = u
+		return map[string]interface{}{"localId": uid}, ""
+
+	case "/accounts:update":
+		uid, _ := body["localId"].(string)
+		u, ok := f.users[uid]
+		if !ok {
+			return nil, "USER_NOT_FOUND"
+		}
+		for k, v := range body {
+			switch k {
+			case "password":
+			case "disableUser":
+				u["disabled"] = v
+			case "deleteAttribute":
+				for _, attr := range v.([]interface{}) {
+					delete(u, map[string]string{"DISPLAY_NAME": "displayName", "PHOTO_URL": "photoUrl"}[attr.(string)])
+				}
+			case "deleteProvider":
+				delete(u, "phoneNumber")
+			default:
+				u[k] = v
+			}
+		}
+		return map[string]interface{}{"localId": uid}, ""
+
+	case "/accounts:batchGet":
+		var uids []string
+		for uid := range f.users {
+			uids = append(uids, uid)
+		}
+		sort.Strings(uids)
+		if token, _ := body["nextPageToken"].(string); token != "" {
+			i := sort.SearchStrings(uids, token)
+			if i == len(uids) || uids[i] != token {
+				return nil, "INVALID_PAGE_SELECTION"
+			}
+			uids = uids[i+1:]
+		}
+		max := 1000
+		if s, ok := body["maxResults"].(string); ok {
+			max, _ = strconv.Atoi(s)
+		}
+		resp := map[string]interface{}{}
+		if len(uids) > max {
+			uids = uids[:max]
+			resp["nextPageToken"] = uids[max-1]
+		}
+		var users []map[string]interface{}
+		for _, uid := range uids {
+			users = append(users, f.users[uid])
+		}
+		resp["users"] = users
+		return resp, ""
+
+	case "/accounts:batchCreate":
+		f.lastImport = body
+		var errs []map[string]interface{}
+		for i, u := range body["users"].([]interface{}) {
+			u := u.(map[string]interface{})
+			uid := u["localId"].(string)
+			if _, ok := f.users[uid]; ok {
+				errs = append(errs, map[string]interface{}{"index": i, "message": "DUPLICATE_LOCAL_ID"})
+				continue
+			}
+			f.users[uid] = u
+		}
+		return map[string]interface{}{"error": errs}, ""
+
+	case "/accounts:batchDelete":
+		if body["force"] != true {
+			return nil, "MISSING_FORCE"
+		}
+		var errs []map[string]interface{}
+		for i, uid := range body["localIds"].([]interface{}) {
+			if u, ok := f.users[uid.(string)]; ok && u["locked"] == true {
+				errs = append(errs, map[string]interface{}{"index": i, "localId": uid, "message": "LOCKED"})
+				continue
+			}
+			delete(f.users, uid.(string))
+		}
+		return map[string]interface{}{"errors": errs}, ""
+
+	case "/accounts:delete":
+		uid, _ := body["localId"].(string)
+		if _, ok := f.users[uid]; !ok {
+			return nil, "USER_NOT_FOUND"
+		}
+		delete(f.users, uid)
+		return map[string]interface{}{}, ""
+	}
+	return nil, "UNKNOWN_PATH"
+}
+
+func TestAdminClient(t *testing.T) {
+	t.Parallel()
+	const projectID = "projectID"
+	fake := newFakeToolkit(t, projectID)
+	fake.users["existing"] = map[string]interface{}{
+		"localId":          "existing",
+		"email":            "existing@example.com",
+		"emailVerified":    true,
+		"phoneNumber":      "+15555550100",
+		"displayName":      "Existing",
+		"customAttributes": `{"admin":true}`,
+		"validSince":       "1500000000",
+		"createdAt":        "1500000000000",
+		"lastLoginAt":      "1500000001000",
+		"lastRefreshAt":    "2017-07-14T02:40:02Z",
+		"providerUserInfo": []interface{}{
+			map[string]interface{}{"providerId": "password", "rawId": "existing@example.com", "email": "existing@example.com"},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	c := fake.newClient()
+
+	want := &UserRecord{
+		UID:              "existing",
+		Email:            "existing@example.com",
+		EmailVerified:    true,
+		PhoneNumber:      "+15555550100",
+		DisplayName:      "Existing",
+		ProviderData:     []ProviderInfo{{ProviderID: "password", UID: "existing@example.com", Email: "existing@example.com"}},
+		CustomClaims:     map[string]interface{}{"admin": true},
+		TokensValidAfter: time.Unix(1500000000, 0),
+		Metadata: UserMetadata{
+			CreationTime:    time.Unix(1500000000, 0),
+			LastSignInTime:  time.Unix(1500000001, 0),
+			LastRefreshTime: time.Date(2017, 7, 14, 2, 40, 2, 0, time.UTC),
+		},
+	}
+	for name, get := range map[string]func() (*UserRecord, error){
+		"GetUser":              func() (*UserRecord, error) { return c.GetUser(ctx, "existing") },
+		"GetUserByEmail":       func() (*UserRecord, error) { return c.GetUserByEmail(ctx, "existing@example.com") },
+		"GetUserByPhoneNumber": func() (*UserRecord, error) { return c.GetUserByPhoneNumber(ctx, "+15555550100") },
+	} {
+		if u, err := get(); err != nil {
+			t.Errorf("%s: got err %v", name, err)
+		} else if !reflect.DeepEqual(u, want) {
+			t.Errorf("%s: got %+v, want %+v", name, u, want)
+		}
+	}
+	if _, err := c.GetUser(ctx, "missing"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("GetUser for missing user: got err %v, want ErrUserNotFound", err)
+	}
+	if _, err := c.GetUser(ctx, ""); err == nil {
+		t.Error("GetUser with empty uid: got nil error")
+	}
+
+	// Create, update and delete a user.
+	u, err := c.CreateUser(ctx, (&UserToCreate{}).Email("new@example.com").Password("secret123").DisplayName("New"))
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if u.UID == "" || u.Email != "new@example.com" || u.DisplayName != "New" {
+		t.Errorf("CreateUser: got %+v", u)
+	}
+	if _, err := c.CreateUser(ctx, (&UserToCreate{}).UID("existing")); err == nil {
+		t.Error("CreateUser with duplicate uid: got nil error")
+	}
+	if _, err := c.CreateUser(ctx, (&UserToCreate{}).Password("short")); err == nil {
+		t.Error("CreateUser with short password: got nil error")
+	}
+
+	u, err = c.UpdateUser(ctx, u.UID, (&UserToUpdate{}).DisplayName("").PhoneNumber("+15555550101").Disabled(true))
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if u.DisplayName != "" || u.PhoneNumber != "+15555550101" || !u.Disabled || u.Email != "new@example.com" {
+		t.Errorf("UpdateUser: got %+v", u)
+	}
+	if _, err := c.UpdateUser(ctx, "missing", (&UserToUpdate{}).Disabled(true)); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("UpdateUser for missing user: got err %v, want ErrUserNotFound", err)
+	}
+	if _, err := c.UpdateUser(ctx, u.UID, &UserToUpdate{}); err == nil {
+		t.Error("UpdateUser with no changes: got nil error")
+	}
+
+	if err := c.DeleteUser(ctx, u.UID); err != nil {
+		t.Errorf("DeleteUser: %v", err)
+	}
+	if _, err := c.GetUser(ctx, u.UID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("GetUser after DeleteUser: got err %v, want ErrUserNotFound", err)
+	}
+	if err := c.DeleteUser(ctx, u.UID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("DeleteUser for missing user: got err %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestSetCustomUserClaims(t *testing.T) {
+	t.Parallel()
+	const projectID = "projectID"
+	fake := newFakeToolkit(t, projectID)
+	fake.users["uid"] = map[string]interface{}{"localId": "uid"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	c := fake.newClient()
+
+	claims := map[string]interface{}{"admin": true, "roles": []interface{}{"editor"}}
+	if err := c.SetCustomUserClaims(ctx, "uid", claims); err != nil {
+		t.Fatalf("SetCustomUserClaims: %v", err)
+	}
+	if u, err := c.GetUser(ctx, "uid"); err != nil {
+		t.Errorf("GetUser: %v", err)
+	} else if !reflect.DeepEqual(u.CustomClaims, claims) {
+		t.Errorf("got custom claims %v, want %v", u.CustomClaims, claims)
+	}
+
+	if err := c.SetCustomUserClaims(ctx, "uid", nil); err != nil {
+		t.Fatalf("SetCustomUserClaims(nil): %v", err)
+	}
+	if u, err := c.GetUser(ctx, "uid"); err != nil {
+		t.Errorf("GetUser: %v", err)
+	} else if len(u.CustomClaims) != 0 {
+		t.Errorf("got custom claims %v after removing them", u.CustomClaims)
+	}
+
+	u, err := c.UpdateUser(ctx, "uid", (&UserToUpdate{}).CustomClaims(map[string]interface{}{"level": "gold"}))
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	} else if u.CustomClaims["level"] != "gold" {
+		t.Errorf("got custom claims %v after UpdateUser", u.CustomClaims)
+	}
+
+	for name, claims := range map[string]map[string]interface{}{
+		"reserved": {"sub": "other"},
+		"too long": {"data": strings.Repeat("x", 1000)},
+		"invalid":  {"ch": make(chan int)},
+	} {
+		if err := c.SetCustomUserClaims(ctx, "uid", claims); err == nil {
+			t.Errorf("%s: got nil error", name)
+		}
+		if _, err := c.UpdateUser(ctx, "uid", (&UserToUpdate{}).CustomClaims(claims)); err == nil {
+			t.Errorf("%s with UpdateUser: got nil error", name)
+		}
+	}
+}
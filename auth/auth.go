@@ -3,13 +3,10 @@ package auth
 import (
 	"context"
 	"crypto/rsa"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"strconv"
-	"strings"
+	"os"
 	"sync"
 	"time"
 
@@ -17,16 +14,66 @@ import (
 	"github.com/SermoDigital/jose/jws"
 )
 
-func NewVerifier(ctx context.Context, projectID string, client *http.Client) *Verifier {
+// NewVerifier returns a Verifier for the ID tokens of the given project.
+// Public keys are fetched in the background using client, until ctx is
+// cancelled or Close is called. If client is nil, http.DefaultClient is used.
+func NewVerifier(ctx context.Context, projectID string, client *http.Client, opts ...VerifierOption) *Verifier {
+	return newVerifier(ctx, projectID, "https://securetoken.google.com/"+projectID, idTokenCertificateURL, client, opts)
+}
+
+// newVerifier returns a Verifier for tokens issued by issuer. Unless
+// overridden by WithKeySource, keys are fetched from certURL.
+func newVerifier(ctx context.Context, projectID, issuer, certURL string, client *http.Client, opts []VerifierOption) *Verifier {
 	if client == nil {
 		client = http.DefaultClient
 	}
 	v := &Verifier{
 		projectID: projectID,
-		issuer:    "https://securetoken.google.com/" + projectID,
-		client:    client,
+		issuer:    issuer,
+		toolkit:   newToolkit(projectID, client),
 		haveCerts: make(chan struct{}),
+		done:      make(chan struct{}),
+		refresh:   make(chan struct{}, 1),
+		fetchDone: make(chan struct{}),
+		now:       time.Now,
+		logger:    slog.Default(),
+		backoff:   DefaultBackoff,
+
+		refreshMargin:      defaultRefreshMargin,
+		minRefreshInterval: defaultMinRefreshInterval,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	if v.keys == nil {
+		v.keys = URLKeySource(certURL, client)
+	}
+	if ls, ok := v.keys.(LoggingKeySource); ok {
+		v.keys = ls.KeySourceWithLogger(v.logger)
+	}
+	if !v.emulatorSet && os.Getenv(emulatorHostEnv) != "" {
+		if isDemoProject(projectID) {
+			v.emulator = true
+		} else {
+			v.logger.Error("auth: ignoring "+emulatorHostEnv+" because the project is not a demo project; "+
+				"tokens are verified normally. Use WithEmulator(true) to enable emulator mode explicitly.",
+				"project", projectID, "emulator_host", os.Getenv(emulatorHostEnv))
+		}
+	}
+	if v.emulator {
+		// Make it impossible to miss that tokens are not being verified.
+		v.logger.Warn("auth: Firebase Auth Emulator mode is enabled; token signatures are NOT verified. "+
+			"This must never happen in production!",
+			"project", projectID, "emulator_host", os.Getenv(emulatorHostEnv))
+		close(v.haveCerts) // no certs needed
+		close(v.done)
+		v.cancel = func() {}
+		return v
 	}
+	if v.cacheDir != "" {
+		v.loadCachedCerts()
+	}
+	ctx, v.cancel = context.WithCancel(ctx)
 	go v.fetchCertLoop(ctx)
 	return v
 }
@@ -34,11 +81,40 @@ func NewVerifier(ctx context.Context, projectID string, client *http.Client) *Ve
 type Verifier struct {
 	projectID string
 	issuer    string
-	client    *http.Client
+	toolkit   *toolkit         // for revocation checks
+	keys      KeySource        // see WithKeySource
+	skew      time.Duration    // tolerance for exp and iat; see WithClockSkew
+	now       func() time.Time // see WithClock
+
+	emulator    bool // accept unsigned emulator tokens; see WithEmulator
+	emulatorSet bool // emulator was set explicitly, so ignore the environment
+
+	tenants map[string]bool // allowed tenant IDs, or nil for any; see WithTenants
+
+	cacheDir string // where to persist certs across restarts; see WithCacheDir
+
+	tokens *tokenCache // verified tokens, or nil; see WithTokenCache
+
+	logger    *slog.Logger       // see WithLogger
+	onRefresh func(RefreshEvent) // see WithRefreshHook
+	metrics   Metrics            // see WithMetrics
+	cancel    func()             // stops fetchCertLoop
+	done      chan struct{}      // closed when fetchCertLoop returns
+	refresh   chan struct{}      // asks fetchCertLoop to refresh early
+
+	backoff            Backoff       // see WithBackoff
+	refreshMargin      time.Duration // see WithRefreshMargin
+	minRefreshInterval time.Duration // see WithMinRefreshInterval
 
 	mu        sync.RWMutex
 	certs     map[string]*rsa.PublicKey
-	haveCerts chan struct{} // closed when we have certs
+	haveCerts chan struct{}  // closed when we have certs
+	status    VerifierStatus // KeyIDs is unset; see Status
+	fetchedAt time.Time      // real time of the last fetch attempt
+	fetchDone chan struct{}  // closed and replaced after each fetch attempt
+
+	revMu      sync.Mutex
+	validSince map[validSinceKey]validSinceEntry // see revoke.go
 }
 
 type User struct {
@@ -46,23 +122,44 @@ type User struct {
 	SignInProvider string
 	EmailVerified  bool
 	Email          string
+
+	// TenantID is the Identity Platform tenant the user belongs to,
+	// from the "firebase.tenant" claim. It is empty for users that
+	// do not belong to a tenant.
+	TenantID string
+
+	// Claims holds every claim in the verified token, including the
+	// standard JWT claims, the "firebase" object and any custom claims.
+	// See the accessor methods in claims.go for typed access.
+	Claims jws.Claims
 }
 
 func (v *Verifier) Verify(ctx context.Context, token []byte) (*User, error) {
+	start := time.Now()
+	u, err := v.verifyCached(ctx, token)
+	v.verifyDone(start, err)
+	return u, err
+}
+
+func (v *Verifier) verify(ctx context.Context, token []byte) (*User, error) {
 	// Verify a signed JWT token according to spec:
 	// https://firebase.google.com/docs/auth/admin/verify-id-tokens
 	tok, err := jws.ParseCompact(token)
 	if err != nil {
-		return nil, fmt.Errorf("auth: parse error: %v", err)
+		return nil, &VerifyError{Reason: ReasonMalformed, Err: err, msg: fmt.Sprintf("auth: parse error: %v", err)}
+	}
+
+	if v.emulator {
+		return v.verifyEmulated(token, tok)
 	}
 
 	// Step 1: Check alg and kid
 	if alg, ok := tok.Protected().Get("alg").(string); !ok || alg != "RS256" {
-		return nil, fmt.Errorf("auth: alg is %s, not RS256", alg)
+		return nil, verifyErrorf(ReasonBadAlgorithm, "auth: alg is %s, not RS256", alg)
 	}
 	kid, ok := tok.Protected().Get("kid").(string)
 	if !ok {
-		return nil, errors.New("auth: invalid kid")
+		return nil, verifyErrorf(ReasonMalformed, "auth: invalid kid")
 	}
 
 	v.mu.RLock()
@@ -72,7 +169,9 @@ func (v *Verifier) Verify(ctx context.Context, token []byte) (*User, error) {
 		v.mu.RUnlock()
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return nil, &VerifyError{Reason: ReasonNoCerts, Err: ctx.Err(), msg: fmt.Sprintf("auth: no certificates available: %v", ctx.Err())}
+		case <-v.done:
+			return nil, &VerifyError{Reason: ReasonNoCerts, Err: ErrClosed, msg: "auth: no certificates available: verifier closed"}
 		case <-v.haveCerts:
 			// We have certs; grab the lock again and continue
 			v.mu.RLock()
@@ -81,146 +180,171 @@ func (v *Verifier) Verify(ctx context.Context, token []byte) (*User, error) {
 	key, ok := v.certs[kid]
 	v.mu.RUnlock()
 
+	// The keys may have been rotated since we last fetched them;
+	// if so, fetch them again and retry once.
+	if !ok && v.refreshKeys(ctx, kid) {
+		v.mu.RLock()
+		key, ok = v.certs[kid]
+		v.mu.RUnlock()
+	}
+
 	if !ok {
-		return nil, fmt.Errorf("auth: unknown kid: %s", kid)
+		return nil, verifyErrorf(ReasonUnknownKeyID, "auth: unknown kid: %s", kid)
 	} else if err := tok.Verify(key, crypto.SigningMethodRS256); err != nil {
-		return nil, fmt.Errorf("auth: verification failure: %v", err)
+		return nil, &VerifyError{Reason: ReasonBadSignature, Err: err, msg: fmt.Sprintf("auth: verification failure: %v", err)}
 	}
 
+	return v.verifyClaims(tok)
+}
+
+// verifyClaims performs the second verification step on a token
+// whose signature has already been verified.
+func (v *Verifier) verifyClaims(tok jws.JWS) (*User, error) {
 	// Step 2: check exp, iat, aud, iss, sub, per spec.
 	claimsMap, ok := tok.Payload().(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("auth: unexpected payload type: %T", tok.Payload())
+		return nil, verifyErrorf(ReasonMalformed, "auth: unexpected payload type: %T", tok.Payload())
 	}
 	claims := jws.Claims(claimsMap)
-	now := time.Now()
+	now := v.now()
 
 	// exp: "Must be in the future. The time is measured in seconds since the UNIX epoch."
-	if exp, ok := claims.Expiration(); !ok || exp.Before(now) {
-		return nil, errors.New("auth: expired token")
+	if exp, ok := claims.Expiration(); !ok || exp.Before(now.Add(-v.skew)) {
+		return nil, ErrExpired
 	}
 
 	// iat: "Must be in the past. The time is measured in seconds since the UNIX epoch."
-	if iat, ok := claims.IssuedAt(); !ok || iat.After(now) {
-		return nil, errors.New("auth: token issued in the future")
+	if iat, ok := claims.IssuedAt(); !ok || iat.After(now.Add(v.skew)) {
+		return nil, ErrNotYetValid
 	}
 
 	// aud: "Must be your Firebase project ID, the unique identifier for your
 	// Firebase project, which can be found in the URL of that project's console."
 	if aud, ok := claims.Get("aud").(string); !ok || aud != v.projectID {
-		return nil, fmt.Errorf("auth: unexpected project ID (%q)", aud)
+		return nil, verifyErrorf(ReasonWrongAudience, "auth: unexpected project ID (%q)", aud)
 	}
 
 	// iss: "Must be "https://securetoken.google.com/<projectId>", where <projectId>
 	// is the same project ID used for aud above."
+	// For session cookies the issuer is "https://session.firebase.google.com/<projectId>".
 	if iss, ok := claims.Get("iss").(string); !ok || iss != v.issuer {
-		return nil, fmt.Errorf("auth: unexpected issuer (%q)", iss)
+		return nil, verifyErrorf(ReasonWrongIssuer, "auth: unexpected issuer (%q)", iss)
 	}
 
 	// sub: "Must be a non-empty string and must be the uid of the user or device."
 	sub, _ := claims.Get("sub").(string)
 	userID, _ := claims.Get("user_id").(string)
 	if sub == "" || userID == "" || sub != userID {
-		return nil, fmt.Errorf("auth: invalid sub or user_id (%q / %q)", sub, userID)
+		return nil, verifyErrorf(ReasonInvalidSubject, "auth: invalid sub or user_id (%q / %q)", sub, userID)
 	}
 
 	u := new(User)
+	u.Claims = claims
 	u.ID = userID
 	u.Email, _ = claims.Get("email").(string)
 	u.EmailVerified, _ = claims.Get("email_verified").(bool)
 	u.SignInProvider, _ = claims.Get("sign_in_provider").(string)
+	if u.SignInProvider == "" {
+		u.SignInProvider, _ = u.firebase()["sign_in_provider"].(string)
+	}
+	u.TenantID, _ = u.firebase()["tenant"].(string)
+	if v.tenants != nil && !v.tenants[u.TenantID] {
+		return nil, verifyErrorf(ReasonWrongTenant, "auth: unexpected tenant (%q)", u.TenantID)
+	}
 	return u, nil
 }
 
 func (v *Verifier) fetchCertLoop(ctx context.Context) {
-	var nextFetch <-chan time.Time
-	failExpiry := 1 * time.Second
-	firstFetch := true
+	defer close(v.done)
+	v.mu.RLock()
+	firstFetch := len(v.certs) == 0 // certs may have been loaded from the cache
+	v.mu.RUnlock()
+	failures := 0
 	for {
-		certs, expiry, err := v.fetchCerts(ctx)
+		certs, expiry, err := v.keys.Keys(ctx)
+		if ctx.Err() != nil {
+			return // closed while fetching
+		}
+		fetched := time.Now()
+		now := v.now()
+		var wait time.Duration
 		if err == nil {
 			v.mu.Lock()
+			if v.tokens != nil && len(v.certs) > 0 && !sameKeys(v.certs, certs) {
+				v.tokens.purge()
+			}
 			v.certs = certs
+			v.status.LastFetch = now
+			v.status.LastSuccess = now
+			v.status.LastError = nil
+			v.fetchFinished(fetched)
 			v.mu.Unlock()
 
 			if firstFetch && len(certs) > 0 {
 				close(v.haveCerts)
 				firstFetch = false
 			}
+			if v.cacheDir != "" {
+				v.saveCachedCerts(certs, now.Add(expiry))
+			}
+			failures = 0
+
+			// Refresh a little before the keys expire, so that
+			// a failed refresh can be retried in time.
+			wait = expiry - v.refreshMargin
+			if wait < v.backoff.Base {
+				wait = v.backoff.Base
+			}
 		} else {
-			// We got an error; check if the certs are empty; in that case,
-			// set a low expiry so we try often and complain loudly.
-			v.mu.RLock()
+			// We got an error; retry with back-off, and complain
+			// loudly if we cannot authenticate anyone.
+			v.mu.Lock()
 			gotCerts := len(v.certs) > 0
-			v.mu.RUnlock()
+			v.status.LastFetch = now
+			v.status.LastError = err
+			v.fetchFinished(fetched)
+			v.mu.Unlock()
 
+			wait = v.backoff.delay(failures)
+			failures++
 			if !gotCerts {
-				log.Println("auth: failed to fetch certs and no certs present -- cannot authenticate users! err:", err)
-				expiry = failExpiry
-				failExpiry *= 2 // exponential back-off
+				v.logger.Error("auth: failed to fetch certs and no certs present -- cannot authenticate users!",
+					"err", err, "retry_in", wait, "attempt", failures)
+			} else {
+				v.logger.Warn("auth: failed to refresh certs, using existing certs",
+					"err", err, "retry_in", wait, "attempt", failures)
 			}
 		}
 
-		nextFetch = time.After(expiry)
-
-		// Return if we're done
-		select {
-		case <-ctx.Done():
-			return
-		case <-nextFetch:
+		v.mu.Lock()
+		v.status.NextRefresh = now.Add(wait)
+		v.mu.Unlock()
+		if v.onRefresh != nil {
+			v.onRefresh(RefreshEvent{Err: err, Keys: len(certs), NextRefresh: now.Add(wait)})
 		}
-	}
-}
-
-var certificateURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
-
-func (v *Verifier) fetchCerts(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
-	req, err := http.NewRequest("GET", certificateURL, nil)
-	if err != nil {
-		// Should never happen for a well-formed URL
-		panic("auth: internal error: could not create request (invalid URL?): " + err.Error())
-	}
-
-	resp, err := v.client.Do(req.WithContext(ctx))
-	if err != nil {
-		return nil, 0, err
-	}
-	defer resp.Body.Close()
-
-	var certs map[string]string
-	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
-		return nil, 0, err
-	}
-
-	parsedCerts := make(map[string]*rsa.PublicKey, len(certs))
-	for key, cert := range certs {
-		pk, err := crypto.ParseRSAPublicKeyFromPEM([]byte(cert))
-		if err != nil {
-			return nil, 0, err
+		if v.metrics != nil {
+			v.metrics.RefreshDone(err)
+			if err == nil {
+				v.metrics.KeysUpdated(len(certs), fetched)
+			}
 		}
-		parsedCerts[key] = pk
-	}
 
-	// Parse max-age into a duration, per the spec:
-	// https://firebase.google.com/docs/auth/admin/verify-id-tokens
-	cacheControl := resp.Header.Get("Cache-Control")
-	parts := strings.Split(cacheControl, ",")
-	expiry := 10 * time.Minute // arbitrary default
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if strings.HasPrefix(part, "max-age=") {
-			seconds, err := strconv.Atoi(part[len("max-age="):])
-			if err != nil {
-				log.Println("auth: could not parse max-age for JWT certificate expiry, defaulting to 10m")
-				break
+		// Wait until the next refresh is due, or until Verify sees
+		// an unknown kid, which may mean the keys have been rotated.
+		timer := time.NewTimer(wait)
+		for waiting := true; waiting; {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				waiting = false
+			case <-v.refresh:
+				if time.Since(fetched) >= v.minRefreshInterval {
+					timer.Stop()
+					waiting = false
+				}
 			}
-			expiry = time.Duration(seconds) * time.Second
 		}
 	}
-
-	// Sanity check against weird max-age values
-	if expiry < 0 {
-		expiry = 10 * time.Minute
-	}
-	return parsedCerts, expiry, nil
 }
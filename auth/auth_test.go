@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
@@ -17,22 +18,26 @@ func TestVerify(t *testing.T) {
 		Key     key
 		Payload map[string]interface{}
 		Err     string
+		Reason  Reason
 		User    *User
 	}{
 		0: {
-			Key: invalidKey,
-			Err: "unknown kid",
+			Key:    invalidKey,
+			Err:    "unknown kid",
+			Reason: ReasonUnknownKeyID,
 		},
 		1: {
-			Key: validKeys[0],
-			Err: "expired token",
+			Key:    validKeys[0],
+			Err:    "expired token",
+			Reason: ReasonExpired,
 		},
 		2: {
 			Key: validKeys[0],
 			Payload: map[string]interface{}{
 				"exp": past,
 			},
-			Err: "expired token",
+			Err:    "expired token",
+			Reason: ReasonExpired,
 		},
 		3: {
 			Key: validKeys[1],
@@ -40,7 +45,8 @@ func TestVerify(t *testing.T) {
 				"exp": future,
 				"iat": future,
 			},
-			Err: "token issued in the future",
+			Err:    "token issued in the future",
+			Reason: ReasonNotYetValid,
 		},
 		4: {
 			Key: validKeys[1],
@@ -49,7 +55,8 @@ func TestVerify(t *testing.T) {
 				"iat": past,
 				"aud": "some-other-project-id",
 			},
-			Err: "unexpected project ID",
+			Err:    "unexpected project ID",
+			Reason: ReasonWrongAudience,
 		},
 		5: {
 			Key: validKeys[1],
@@ -59,7 +66,8 @@ func TestVerify(t *testing.T) {
 				"aud": projectID,
 				"iss": "some-random-value",
 			},
-			Err: "unexpected issuer",
+			Err:    "unexpected issuer",
+			Reason: ReasonWrongIssuer,
 		},
 		6: {
 			Key: validKeys[2],
@@ -69,7 +77,8 @@ func TestVerify(t *testing.T) {
 				"aud": projectID,
 				"iss": "https://securetoken.google.com/some-other-project-id",
 			},
-			Err: "unexpected issuer",
+			Err:    "unexpected issuer",
+			Reason: ReasonWrongIssuer,
 		},
 		7: {
 			Key: validKeys[0],
@@ -79,7 +88,8 @@ func TestVerify(t *testing.T) {
 				"aud": projectID,
 				"iss": "https://securetoken.google.com/" + projectID,
 			},
-			Err: "invalid sub or user_id",
+			Err:    "invalid sub or user_id",
+			Reason: ReasonInvalidSubject,
 		},
 		8: {
 			Key: validKeys[0],
@@ -91,7 +101,8 @@ func TestVerify(t *testing.T) {
 				"sub":     "sub",
 				"user_id": "some-other-value",
 			},
-			Err: "invalid sub or user_id",
+			Err:    "invalid sub or user_id",
+			Reason: ReasonInvalidSubject,
 		},
 		9: {
 			Key: validKeys[0],
@@ -132,7 +143,7 @@ func TestVerify(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
-	verifier := NewVerifier(ctx, projectID, nil)
+	verifier := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys))
 	for i, test := range tests {
 		payload := test.Payload
 		if payload == nil {
@@ -152,6 +163,12 @@ func TestVerify(t *testing.T) {
 			continue
 		}
 		if err != nil {
+			var verr *VerifyError
+			if !errors.As(err, &verr) {
+				t.Errorf("%d: got err of type %T, want *VerifyError", i, err)
+			} else if verr.Reason != test.Reason {
+				t.Errorf("%d: got reason %v, want %v", i, verr.Reason, test.Reason)
+			}
 			continue // no point checking *User
 		}
 
@@ -159,8 +176,41 @@ func TestVerify(t *testing.T) {
 			t.Errorf("%d: want or got nil user (incorrectly written test?)", i)
 			continue
 		}
-		if !reflect.DeepEqual(*test.User, *user) {
-			t.Errorf("%d: got user %+v, want %+v", i, *user, *test.User)
+		if user.Claims == nil {
+			t.Errorf("%d: got nil claims", i)
+		}
+		got := *user
+		got.Claims = nil // checked separately in claims_test.go
+		if !reflect.DeepEqual(*test.User, got) {
+			t.Errorf("%d: got user %+v, want %+v", i, got, *test.User)
 		}
 	}
 }
+
+func TestVerifyErrorIs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	verifier := NewVerifier(ctx, "projectID", nil, WithKeySource(testKeys))
+
+	_, err := verifier.Verify(ctx, genToken(map[string]interface{}{}, validKeys[0]))
+	if !errors.Is(err, ErrExpired) {
+		t.Errorf("got err %v, want ErrExpired", err)
+	}
+	if errors.Is(err, ErrWrongAudience) {
+		t.Errorf("err %v unexpectedly matches ErrWrongAudience", err)
+	}
+
+	_, err = verifier.Verify(ctx, []byte("not a token"))
+	if !errors.Is(err, ErrMalformed) {
+		t.Errorf("got err %v, want ErrMalformed", err)
+	}
+
+	// With no certificates, Verify waits until the context is done.
+	noCerts := &Verifier{haveCerts: make(chan struct{})}
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+	_, err = noCerts.Verify(shortCtx, genToken(map[string]interface{}{}, validKeys[0]))
+	if !errors.Is(err, ErrNoCerts) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got err %v, want ErrNoCerts wrapping context.DeadlineExceeded", err)
+	}
+}
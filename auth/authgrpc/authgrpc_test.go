@@ -0,0 +1,93 @@
+package authgrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cmelbye/firebase-go/auth"
+	"github.com/cmelbye/firebase-go/auth/authtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func TestInterceptors(t *testing.T) {
+	srv := authtest.NewServer("projectID")
+	defer srv.Close()
+
+	valid := srv.Token("sub").String()
+	expired := srv.Token("sub").Expired().String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	verifier := srv.Verifier(ctx)
+	defer verifier.Close()
+
+	const (
+		method = "/test.Service/Method"
+		health = "/grpc.health.v1.Health/Check"
+	)
+	var tests = []struct {
+		Verifier auth.TokenVerifier
+		Method   string
+		Token    string
+		Code     codes.Code
+		User     string
+	}{
+		0: {Method: method, Code: codes.Unauthenticated},
+		1: {Method: method, Token: "Bearer " + valid, Code: codes.OK, User: "sub"},
+		2: {Method: method, Token: valid, Code: codes.Unauthenticated},
+		3: {Method: method, Token: "Bearer " + expired, Code: codes.Unauthenticated},
+		4: {Method: health, Code: codes.OK},
+		5: {Method: method, Token: "Bearer " + valid, Verifier: verifier.ForTenant("tenant"), Code: codes.PermissionDenied},
+		6: {Method: method, Token: "Basic dXNlcjpwYXNz", Code: codes.Unauthenticated},
+	}
+
+	for i, test := range tests {
+		v := test.Verifier
+		if v == nil {
+			v = verifier
+		}
+		callCtx := ctx
+		if test.Token != "" {
+			callCtx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", test.Token))
+		}
+		check := func(kind string, gotUser string, err error) {
+			if code := status.Code(err); code != test.Code {
+				t.Errorf("%d: %s: got code %v, want %v (err %v)", i, kind, code, test.Code, err)
+			}
+			if gotUser != test.User {
+				t.Errorf("%d: %s: got user %q, want %q", i, kind, gotUser, test.User)
+			}
+		}
+
+		var user string
+		unary := UnaryServerInterceptor(v, SkipMethods(health))
+		_, err := unary(callCtx, nil, &grpc.UnaryServerInfo{FullMethod: test.Method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			if u, ok := auth.UserFromContext(ctx); ok {
+				user = u.ID
+			}
+			return nil, nil
+		})
+		check("unary", user, err)
+
+		user = ""
+		stream := StreamServerInterceptor(v, SkipMethods(health))
+		err = stream(nil, &fakeStream{ctx: callCtx}, &grpc.StreamServerInfo{FullMethod: test.Method}, func(srv interface{}, ss grpc.ServerStream) error {
+			if u, ok := auth.UserFromContext(ss.Context()); ok {
+				user = u.ID
+			}
+			return nil
+		})
+		check("stream", user, err)
+	}
+}
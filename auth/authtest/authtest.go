@@ -0,0 +1,159 @@
+// Package authtest provides utilities for testing code that verifies
+// Firebase ID tokens with package auth.
+//
+// A Server serves public keys the way Google does, and mints tokens
+// signed with the matching private keys:
+//
+//	srv := authtest.NewServer("my-project")
+//	defer srv.Close()
+//
+//	v := srv.Verifier(ctx)
+//	defer v.Close()
+//	handler := auth.Middleware(v)(myHandler)
+//
+//	req := httptest.NewRequest("GET", "/", nil)
+//	req.Header.Set("Authorization", "Bearer "+srv.Token("alice").Email("alice@example.com", true).String())
+package authtest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/cmelbye/firebase-go/auth"
+)
+
+// A Server is a fake Google certificate server, serving X.509
+// certificates in the same format as the real one.
+type Server struct {
+	// URL of the certificates, of the form http://ipaddr:port with no trailing slash.
+	URL string
+
+	// ProjectID is the project the Server mints tokens for.
+	ProjectID string
+
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	keys    []*signingKey // served keys, signing key last
+	unknown *signingKey   // not served; see TokenBuilder.SignedWithUnknownKey
+}
+
+type signingKey struct {
+	id   string
+	priv *rsa.PrivateKey
+	cert string // PEM encoded
+}
+
+// NewServer starts and returns a new Server serving a single key.
+// The caller should call Close when finished, to shut it down.
+func NewServer(projectID string) *Server {
+	s := &Server{
+		ProjectID: projectID,
+		keys:      []*signingKey{newSigningKey()},
+		unknown:   newSigningKey(),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveKeys))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// KeySource returns a KeySource that fetches keys from the server.
+func (s *Server) KeySource() auth.KeySource {
+	return auth.X509KeySource(s.URL, s.srv.Client())
+}
+
+// Verifier returns a Verifier for the server's project that fetches
+// keys from the server. Emulator mode is disabled, regardless of the
+// environment, and the Verifier fetches the keys again whenever it sees
+// an unknown key ID, without the usual minimum interval, so that tokens
+// signed after RotateKey are accepted straight away. opts are applied
+// after those, so they can override them.
+// The caller should call Close on the Verifier when finished.
+func (s *Server) Verifier(ctx context.Context, opts ...auth.VerifierOption) *auth.Verifier {
+	opts = append([]auth.VerifierOption{
+		auth.WithKeySource(s.KeySource()),
+		auth.WithEmulator(false),
+		auth.WithMinRefreshInterval(0),
+	}, opts...)
+	return auth.NewVerifier(ctx, s.ProjectID, s.srv.Client(), opts...)
+}
+
+// RotateKey generates a new key and signs subsequent tokens with it.
+// The server keeps serving the previous keys, as Google does while
+// tokens signed with them may still be valid.
+//
+// A Verifier returned by Server.Verifier fetches the new key when it
+// first sees a token signed with it. Other Verifiers only do so after
+// their minimum refresh interval (see auth.WithMinRefreshInterval).
+func (s *Server) RotateKey() {
+	k := newSigningKey()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, k)
+}
+
+// signingKey returns the key tokens are currently signed with.
+func (s *Server) signingKey() *signingKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[len(s.keys)-1]
+}
+
+func (s *Server) serveKeys(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	certs := make(map[string]string, len(s.keys))
+	for _, k := range s.keys {
+		certs[k.id] = k.cert
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600, must-revalidate, no-transform")
+	json.NewEncoder(w).Encode(certs)
+}
+
+// newSigningKey generates a key and a self-signed certificate for it.
+func newSigningKey() *signingKey {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic("authtest: could not generate key: " + err.Error())
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		panic("authtest: could not generate serial number: " + err.Error())
+	}
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "securetoken.system.gserviceaccount.com"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		panic("authtest: could not create certificate: " + err.Error())
+	}
+	return &signingKey{
+		id:   serial.Text(16),
+		priv: priv,
+		cert: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}
+}
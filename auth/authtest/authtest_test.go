@@ -0,0 +1,78 @@
+package authtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cmelbye/firebase-go/auth"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer("projectID")
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	v := srv.Verifier(ctx)
+	defer v.Close()
+
+	u, err := v.Verify(ctx, srv.Token("alice").Email("alice@example.com", true).Tenant("tenant").Claim("admin", true).Bytes())
+	if err != nil {
+		t.Fatalf("valid token: got err %v", err)
+	}
+	if u.ID != "alice" || u.Email != "alice@example.com" || !u.EmailVerified || u.TenantID != "tenant" || u.SignInProvider != "custom" {
+		t.Errorf("got user %+v", u)
+	}
+	if u.Claims.Get("admin") != true {
+		t.Errorf("got admin claim %v, want true", u.Claims.Get("admin"))
+	}
+
+	for _, test := range []struct {
+		name  string
+		token *TokenBuilder
+		want  error
+	}{
+		{"expired", srv.Token("alice").Expired(), auth.ErrExpired},
+		{"wrong audience", srv.Token("alice").WrongAudience(), auth.ErrWrongAudience},
+		{"wrong issuer", srv.Token("alice").Issuer("https://example.com"), auth.ErrWrongIssuer},
+		{"unknown key", srv.Token("alice").SignedWithUnknownKey(), auth.ErrUnknownKeyID},
+		{"no kid", srv.Token("alice").WithoutKeyID(), auth.ErrMalformed},
+	} {
+		if _, err := v.Verify(ctx, test.token.Bytes()); !errors.Is(err, test.want) {
+			t.Errorf("%s: got err %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	// Tokens signed after a rotation are accepted, and so are
+	// tokens signed before it.
+	old := srv.Token("alice").Bytes()
+	srv.RotateKey()
+	if _, err := v.Verify(ctx, srv.Token("bob").Bytes()); err != nil {
+		t.Errorf("token signed with rotated key: got err %v", err)
+	}
+	if _, err := v.Verify(ctx, old); err != nil {
+		t.Errorf("token signed with previous key: got err %v", err)
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	srv := NewServer("projectID")
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	v := srv.Verifier(ctx)
+	defer v.Close()
+	if _, err := v.Verify(ctx, srv.Token("alice").Bytes()); err != nil {
+		t.Fatalf("token before rotation: got err %v", err)
+	}
+
+	// The Verifier has just fetched the keys, yet
+	// picks up the new one immediately.
+	srv.RotateKey()
+	if _, err := v.Verify(ctx, srv.Token("alice").Bytes()); err != nil {
+		t.Errorf("token after rotation: got err %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package authtest
+
+import (
+	"time"
+
+	"github.com/SermoDigital/jose/crypto"
+	"github.com/SermoDigital/jose/jws"
+)
+
+// A TokenBuilder builds an ID token. Its setters return the builder,
+// so that calls can be chained:
+//
+//	token := srv.Token("alice").Email("alice@example.com", true).Expired().Bytes()
+//
+// By default, the token is valid for an hour from now and is signed
+// with the server's current key.
+type TokenBuilder struct {
+	s       *Server
+	claims  jws.Claims
+	key     *signingKey
+	noKeyID bool
+}
+
+// Token returns a builder for an ID token identifying the user uid,
+// with the claims of a token issued now for a custom-token sign-in.
+func (s *Server) Token(uid string) *TokenBuilder {
+	now := time.Now()
+	return &TokenBuilder{
+		s: s,
+		claims: jws.Claims{
+			"iss":       "https://securetoken.google.com/" + s.ProjectID,
+			"aud":       s.ProjectID,
+			"auth_time": now.Unix(),
+			"iat":       now.Unix(),
+			"exp":       now.Add(time.Hour).Unix(),
+			"sub":       uid,
+			"user_id":   uid,
+			"firebase": map[string]interface{}{
+				"identities":       map[string]interface{}{},
+				"sign_in_provider": "custom",
+			},
+		},
+	}
+}
+
+// Claim sets the claim name to value. It can override any other claim.
+func (b *TokenBuilder) Claim(name string, value interface{}) *TokenBuilder {
+	b.claims[name] = value
+	return b
+}
+
+// Email sets the "email" and "email_verified" claims.
+func (b *TokenBuilder) Email(email string, verified bool) *TokenBuilder {
+	b.claims["email"] = email
+	b.claims["email_verified"] = verified
+	return b
+}
+
+// SignInProvider sets the provider the user signed in with,
+// such as "password" or "google.com".
+func (b *TokenBuilder) SignInProvider(provider string) *TokenBuilder {
+	b.firebase()["sign_in_provider"] = provider
+	return b
+}
+
+// Tenant sets the Identity Platform tenant of the user.
+func (b *TokenBuilder) Tenant(tenantID string) *TokenBuilder {
+	b.firebase()["tenant"] = tenantID
+	return b
+}
+
+// IssuedAt sets the "iat" and "auth_time" claims.
+func (b *TokenBuilder) IssuedAt(t time.Time) *TokenBuilder {
+	b.claims["iat"] = t.Unix()
+	b.claims["auth_time"] = t.Unix()
+	return b
+}
+
+// ExpiresAt sets the "exp" claim.
+func (b *TokenBuilder) ExpiresAt(t time.Time) *TokenBuilder {
+	b.claims["exp"] = t.Unix()
+	return b
+}
+
+// Expired makes the token one that was issued two hours ago
+// and expired an hour ago.
+func (b *TokenBuilder) Expired() *TokenBuilder {
+	now := time.Now()
+	return b.IssuedAt(now.Add(-2 * time.Hour)).ExpiresAt(now.Add(-time.Hour))
+}
+
+// Audience sets the "aud" claim, which must be the project ID.
+func (b *TokenBuilder) Audience(aud string) *TokenBuilder {
+	b.claims["aud"] = aud
+	return b
+}
+
+// WrongAudience makes the token one issued for another project.
+func (b *TokenBuilder) WrongAudience() *TokenBuilder {
+	other := "other-project"
+	if b.s.ProjectID == other {
+		other = "another-project"
+	}
+	return b.Audience(other).Issuer("https://securetoken.google.com/" + other)
+}
+
+// Issuer sets the "iss" claim.
+func (b *TokenBuilder) Issuer(iss string) *TokenBuilder {
+	b.claims["iss"] = iss
+	return b
+}
+
+// SignedWithUnknownKey signs the token with a key the server does not
+// serve, so that the token's key ID is unknown to a Verifier.
+func (b *TokenBuilder) SignedWithUnknownKey() *TokenBuilder {
+	b.key = b.s.unknown
+	return b
+}
+
+// WithoutKeyID omits the "kid" header.
+func (b *TokenBuilder) WithoutKeyID() *TokenBuilder {
+	b.noKeyID = true
+	return b
+}
+
+// Bytes signs and returns the token.
+func (b *TokenBuilder) Bytes() []byte {
+	key := b.key
+	if key == nil {
+		key = b.s.signingKey()
+	}
+	claims := make(jws.Claims, len(b.claims))
+	for name, value := range b.claims {
+		claims[name] = value
+	}
+	tok := jws.New(map[string]interface{}(claims), crypto.SigningMethodRS256)
+	if !b.noKeyID {
+		tok.Protected().Set("kid", key.id)
+	}
+	serialized, err := tok.Compact(key.priv)
+	if err != nil {
+		panic("authtest: could not serialize token: " + err.Error())
+	}
+	return serialized
+}
+
+// String signs and returns the token.
+func (b *TokenBuilder) String() string {
+	return string(b.Bytes())
+}
+
+// firebase returns the "firebase" claim.
+func (b *TokenBuilder) firebase() map[string]interface{} {
+	fb, ok := b.claims["firebase"].(map[string]interface{})
+	if !ok {
+		fb = make(map[string]interface{})
+		b.claims["firebase"] = fb
+	}
+	return fb
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	// defaultRefreshMargin is how long before the keys expire
+	// they are refreshed; see WithRefreshMargin.
+	defaultRefreshMargin = 1 * time.Minute
+
+	// defaultMinRefreshInterval limits how often an unknown kid
+	// can trigger an early refresh; see WithMinRefreshInterval.
+	defaultMinRefreshInterval = 30 * time.Second
+)
+
+// Backoff is a policy for retrying failed attempts to fetch public keys.
+//
+// The n'th retry (counting from zero) waits Base * 2^n, capped at Max,
+// and randomly adjusted by up to ±Jitter of that duration so that
+// many servers do not retry in lockstep.
+type Backoff struct {
+	Base   time.Duration
+	Max    time.Duration
+	Jitter float64 // between 0 and 1
+}
+
+// DefaultBackoff is the Backoff used unless WithBackoff is given.
+var DefaultBackoff = Backoff{
+	Base:   1 * time.Second,
+	Max:    5 * time.Minute,
+	Jitter: 0.2,
+}
+
+// delay returns how long to wait before retry number n.
+func (b Backoff) delay(n int) time.Duration {
+	d := b.Base
+	for i := 0; i < n && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	if b.Jitter > 0 {
+		d += time.Duration(b.Jitter * (2*rand.Float64() - 1) * float64(d))
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	return d
+}
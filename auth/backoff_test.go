@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: time.Minute}
+	want := []time.Duration{1, 2, 4, 8, 16, 32, 60, 60}
+	for n, w := range want {
+		if got := b.delay(n); got != w*time.Second {
+			t.Errorf("delay(%d): got %v, want %v", n, got, w*time.Second)
+		}
+	}
+	if got := b.delay(1000); got != time.Minute {
+		t.Errorf("delay(1000): got %v, want %v", got, time.Minute)
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := b.delay(2); got < 2*time.Second || got > 6*time.Second {
+			t.Fatalf("delay(2) with jitter: got %v, want within [2s, 6s]", got)
+		}
+		if got := b.delay(10); got < 30*time.Second || got > time.Minute {
+			t.Fatalf("delay(10) with jitter: got %v, want within [30s, 1m]", got)
+		}
+	}
+}
+
+// scheduleKeySource serves validKeys[0] with the given expiry,
+// failing every fetch after the first ok ones.
+type scheduleKeySource struct {
+	expiry time.Duration
+	ok     int
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *scheduleKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls > s.ok {
+		return nil, 0, errors.New("unreachable")
+	}
+	k := validKeys[0]
+	return map[string]*rsa.PublicKey{k.id: &k.pk.PublicKey}, s.expiry, nil
+}
+
+// runSchedule runs a Verifier on src until it has made n fetches, and
+// returns the delay it scheduled after each one. The Verifier's clock
+// is stopped, so the delays are exact however long the fetches take.
+func runSchedule(t *testing.T, src *scheduleKeySource, n int, opts ...VerifierOption) (*Verifier, []time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	now := time.Now()
+	events := make(chan RefreshEvent, n)
+	opts = append(opts, WithKeySource(src), WithClock(func() time.Time { return now }),
+		WithRefreshHook(func(ev RefreshEvent) {
+			select {
+			case events <- ev:
+			default:
+			}
+		}))
+	v := NewVerifier(context.Background(), "projectID", nil, opts...)
+	t.Cleanup(func() { v.Close() })
+	var delays []time.Duration
+	for i := 0; i < n; i++ {
+		select {
+		case ev := <-events:
+			delays = append(delays, ev.NextRefresh.Sub(now))
+		case <-ctx.Done():
+			t.Fatalf("got %d fetches, want %d", i, n)
+		}
+	}
+	return v, delays
+}
+
+func TestRefreshSchedule(t *testing.T) {
+	noJitter := WithBackoff(Backoff{Base: 10 * time.Millisecond, Max: time.Second})
+	const ms = time.Millisecond
+
+	var tests = []struct {
+		Src    *scheduleKeySource
+		Margin time.Duration
+		Want   []time.Duration
+		Err    bool // whether the last fetch failed
+	}{
+		// Keys are refreshed the margin before they expire.
+		0: {
+			Src:    &scheduleKeySource{expiry: 50 * ms, ok: 100},
+			Margin: 30 * ms,
+			Want:   []time.Duration{20 * ms, 20 * ms, 20 * ms},
+		},
+		// If the keys expire within the margin, refreshes are
+		// still at least the backoff base apart.
+		1: {
+			Src:    &scheduleKeySource{expiry: 5 * ms, ok: 100},
+			Margin: time.Minute,
+			Want:   []time.Duration{10 * ms, 10 * ms, 10 * ms},
+		},
+		// Failed refreshes are retried with increasing delays,
+		// while the old keys remain in use.
+		2: {
+			Src:  &scheduleKeySource{expiry: 50 * ms, ok: 1},
+			Want: []time.Duration{50 * ms, 10 * ms, 20 * ms, 40 * ms},
+			Err:  true,
+		},
+	}
+	for i, test := range tests {
+		v, got := runSchedule(t, test.Src, len(test.Want), noJitter, WithRefreshMargin(test.Margin))
+		if !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("%d: got delays %v, want %v", i, got, test.Want)
+		}
+		if st := v.Status(); !st.Ready || (st.LastError != nil) != test.Err {
+			t.Errorf("%d: got status %+v", i, st)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxDeleteUsers is the most users deleted in a single request.
+	maxDeleteUsers = 1000
+
+	// maxGetUsers is the most identifiers looked up in a single request.
+	maxGetUsers = 100
+)
+
+// ItemError describes an item of a bulk operation that failed.
+type ItemError struct {
+	// Index is the position of the item in the slice
+	// passed to the operation.
+	Index int
+
+	// Reason explains why the item failed.
+	Reason string
+}
+
+// batchItemError is an error the server reports for one item
+// of a batch, identified by its position in the request.
+type batchItemError struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+// bulkResult accumulates the outcome of a bulk operation.
+//
+// Bulk operations share a contract: invalid items and items the
+// server rejects are reported as ItemErrors without affecting the
+// others, and if a batch fails as a whole, the operation returns the
+// result of the previous batches along with the error. An empty
+// input gives an empty result.
+type bulkResult struct {
+	successes int
+	errors    []ItemError
+}
+
+// run processes n items in batches of at most size. For each item of
+// a batch, add includes it in the batch's request or returns why it is
+// invalid; send then sends the request, unless it is empty, and returns
+// the errors the server reported for individual items.
+func (r *bulkResult) run(n, size int, add func(i int) error, send func() ([]batchItemError, error)) error {
+	defer func() {
+		sort.Slice(r.errors, func(i, j int) bool { return r.errors[i].Index < r.errors[j].Index })
+	}()
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+
+		// indexes maps positions in the request to item indexes.
+		var indexes []int
+		for i := start; i < end; i++ {
+			if err := add(i); err != nil {
+				r.fail(i, err.Error())
+				continue
+			}
+			indexes = append(indexes, i)
+		}
+		if len(indexes) == 0 {
+			continue
+		}
+
+		itemErrs, err := send()
+		if err != nil {
+			return err
+		}
+		failed := make(map[int]bool, len(itemErrs))
+		for _, e := range itemErrs {
+			if e.Index < 0 || e.Index >= len(indexes) || failed[e.Index] {
+				continue
+			}
+			failed[e.Index] = true
+			r.fail(indexes[e.Index], e.Message)
+		}
+		r.successes += len(indexes) - len(failed)
+	}
+	return nil
+}
+
+func (r *bulkResult) fail(index int, reason string) {
+	r.errors = append(r.errors, ItemError{Index: index, Reason: reason})
+}
+
+// DeleteUsersResult reports the outcome of AdminClient.DeleteUsers.
+type DeleteUsersResult struct {
+	SuccessCount int
+	FailureCount int
+
+	// Errors describes each user that was not deleted,
+	// in the order of the users.
+	Errors []ItemError
+}
+
+// DeleteUsers deletes the users with the given IDs, in batches of
+// 1000. Users that do not exist are counted as deleted. Invalid IDs
+// and users that the server fails to delete are reported in the result
+// and do not prevent the others from being deleted. If a batch fails
+// as a whole, DeleteUsers returns the result of the previous batches
+// along with the error.
+func (c *AdminClient) DeleteUsers(ctx context.Context, uids []string) (*DeleteUsersResult, error) {
+	var batch []string
+	add := func(i int) error {
+		if err := validateUID(uids[i]); err != nil {
+			return err
+		}
+		batch = append(batch, uids[i])
+		return nil
+	}
+	send := func() ([]batchItemError, error) {
+		req := struct {
+			LocalIDs []string `json:"localIds"`
+			Force    bool     `json:"force"` // delete users that are not disabled
+		}{batch, true}
+		batch = nil
+		var resp struct {
+			Errors []batchItemError `json:"errors"`
+		}
+		err := c.toolkit.post(ctx, "/accounts:batchDelete", req, &resp)
+		return resp.Errors, err
+	}
+
+	var bulk bulkResult
+	err := bulk.run(len(uids), maxDeleteUsers, add, send)
+	return &DeleteUsersResult{
+		SuccessCount: bulk.successes,
+		FailureCount: len(bulk.errors),
+		Errors:       bulk.errors,
+	}, err
+}
+
+// A UserIdentifier identifies a user for AdminClient.GetUsers.
+// It is one of UIDIdentifier, EmailIdentifier, PhoneIdentifier
+// and ProviderIdentifier.
+type UserIdentifier interface {
+	// addTo validates the identifier and adds it to a lookup request.
+	addTo(req *lookupRequest) error
+
+	// matches reports whether the identifier identifies u.
+	matches(u *UserRecord) bool
+}
+
+// UIDIdentifier identifies a user by user ID.
+type UIDIdentifier struct {
+	UID string
+}
+
+// EmailIdentifier identifies a user by email address.
+type EmailIdentifier struct {
+	Email string
+}
+
+// PhoneIdentifier identifies a user by phone number, in E.164 format.
+type PhoneIdentifier struct {
+	PhoneNumber string
+}
+
+// ProviderIdentifier identifies a user by their ID at an identity
+// provider, such as "google.com".
+type ProviderIdentifier struct {
+	ProviderID  string
+	ProviderUID string
+}
+
+// lookupRequest is an accounts:lookup request.
+type lookupRequest struct {
+	LocalID         []string          `json:"localId,omitempty"`
+	Email           []string          `json:"email,omitempty"`
+	PhoneNumber     []string          `json:"phoneNumber,omitempty"`
+	FederatedUserID []federatedUserID `json:"federatedUserId,omitempty"`
+}
+
+type federatedUserID struct {
+	ProviderID string `json:"providerId"`
+	RawID      string `json:"rawId"`
+}
+
+func (id UIDIdentifier) addTo(req *lookupRequest) error {
+	if err := validateUID(id.UID); err != nil {
+		return err
+	}
+	req.LocalID = append(req.LocalID, id.UID)
+	return nil
+}
+
+func (id UIDIdentifier) matches(u *UserRecord) bool {
+	return u.UID == id.UID
+}
+
+func (id EmailIdentifier) addTo(req *lookupRequest) error {
+	if err := validateUserParams(map[string]interface{}{"email": id.Email}); err != nil {
+		return err
+	}
+	req.Email = append(req.Email, id.Email)
+	return nil
+}
+
+func (id EmailIdentifier) matches(u *UserRecord) bool {
+	return strings.EqualFold(u.Email, id.Email)
+}
+
+func (id PhoneIdentifier) addTo(req *lookupRequest) error {
+	if err := validateUserParams(map[string]interface{}{"phoneNumber": id.PhoneNumber}); err != nil {
+		return err
+	}
+	req.PhoneNumber = append(req.PhoneNumber, id.PhoneNumber)
+	return nil
+}
+
+func (id PhoneIdentifier) matches(u *UserRecord) bool {
+	return u.PhoneNumber == id.PhoneNumber
+}
+
+func (id ProviderIdentifier) addTo(req *lookupRequest) error {
+	if id.ProviderID == "" || id.ProviderUID == "" {
+		return errors.New("auth: provider identifier requires a provider ID and UID")
+	}
+	req.FederatedUserID = append(req.FederatedUserID, federatedUserID{ProviderID: id.ProviderID, RawID: id.ProviderUID})
+	return nil
+}
+
+func (id ProviderIdentifier) matches(u *UserRecord) bool {
+	for _, p := range u.ProviderData {
+		if p.ProviderID == id.ProviderID && p.UID == id.ProviderUID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUsersResult reports the outcome of AdminClient.GetUsers.
+type GetUsersResult struct {
+	// Users holds the users that were found, each once,
+	// in no particular order.
+	Users []*UserRecord
+
+	// NotFound holds the identifiers that did not match a user,
+	// in the order they were given.
+	NotFound []UserIdentifier
+
+	// Errors describes each identifier that was invalid,
+	// in the order of the identifiers.
+	Errors []ItemError
+}
+
+// GetUsers returns the users identified by ids, looking them up in
+// batches of 100. Invalid identifiers are reported in the result and
+// do not prevent the others from being looked up. If a batch fails as
+// a whole, GetUsers returns the result of the previous batches along
+// with the error.
+func (c *AdminClient) GetUsers(ctx context.Context, ids []UserIdentifier) (*GetUsersResult, error) {
+	result := new(GetUsersResult)
+	seen := make(map[string]bool) // user IDs in result.Users
+	var req lookupRequest
+	var batch []UserIdentifier
+	add := func(i int) error {
+		if ids[i] == nil {
+			return errors.New("auth: nil identifier")
+		}
+		if err := ids[i].addTo(&req); err != nil {
+			return err
+		}
+		batch = append(batch, ids[i])
+		return nil
+	}
+	send := func() ([]batchItemError, error) {
+		defer func() {
+			req, batch = lookupRequest{}, nil
+		}()
+		var resp struct {
+			Users []userResponse `json:"users"`
+		}
+		if err := c.toolkit.post(ctx, "/accounts:lookup", req, &resp); err != nil {
+			return nil, err
+		}
+		users := make([]*UserRecord, 0, len(resp.Users))
+		for i := range resp.Users {
+			u, err := resp.Users[i].record()
+			if err != nil {
+				return nil, err
+			}
+			users = append(users, u)
+		}
+		for _, u := range users {
+			if !seen[u.UID] {
+				seen[u.UID] = true
+				result.Users = append(result.Users, u)
+			}
+		}
+	identifiers:
+		for _, id := range batch {
+			for _, u := range users {
+				if id.matches(u) {
+					continue identifiers
+				}
+			}
+			result.NotFound = append(result.NotFound, id)
+		}
+		return nil, nil
+	}
+
+	var bulk bulkResult
+	err := bulk.run(len(ids), maxGetUsers, add, send)
+	result.Errors = bulk.errors
+	return result, err
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeleteUsers(t *testing.T) {
+	t.Parallel()
+	const projectID = "projectID"
+	fake := newFakeToolkit(t, projectID)
+	uids := make([]string, 1500)
+	for i := range uids {
+		uids[i] = fmt.Sprintf("user%04d", i)
+		fake.users[uids[i]] = map[string]interface{}{"localId": uids[i]}
+	}
+	fake.users["user1100"]["locked"] = true
+	uids[5] = ""
+	uids = append(uids, "missing")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := fake.newClient()
+
+	result, err := c.DeleteUsers(ctx, uids)
+	if err != nil {
+		t.Fatalf("DeleteUsers: %v", err)
+	}
+	if fake.requests != 2 {
+		t.Errorf("got %d requests, want 2", fake.requests)
+	}
+	if result.SuccessCount != 1499 || result.FailureCount != 2 {
+		t.Errorf("got %d successes and %d failures, want 1499 and 2", result.SuccessCount, result.FailureCount)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Index != 5 || result.Errors[1].Index != 1100 || result.Errors[1].Reason != "LOCKED" {
+		t.Errorf("got errors %+v", result.Errors)
+	}
+	if len(fake.users) != 2 {
+		t.Errorf("got %d users left, want 2", len(fake.users))
+	}
+}
+
+func TestGetUsers(t *testing.T) {
+	t.Parallel()
+	const projectID = "projectID"
+	fake := newFakeToolkit(t, projectID)
+	for i := 0; i < 150; i++ {
+		uid := fmt.Sprintf("user%03d", i)
+		fake.users[uid] = map[string]interface{}{
+			"localId":     uid,
+			"email":       uid + "@example.com",
+			"phoneNumber": fmt.Sprintf("+1555555%04d", i),
+			"providerUserInfo": []interface{}{
+				map[string]interface{}{"providerId": "google.com", "rawId": "google-" + uid},
+			},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := fake.newClient()
+
+	var ids []UserIdentifier
+	for i := 0; i < 120; i++ {
+		ids = append(ids, UIDIdentifier{fmt.Sprintf("user%03d", i)})
+	}
+	ids = append(ids,
+		EmailIdentifier{"user120@example.com"},
+		PhoneIdentifier{"+15555550121"},
+		ProviderIdentifier{"google.com", "google-user122"},
+		UIDIdentifier{"user000"}, // duplicate
+		UIDIdentifier{"missing"},
+		EmailIdentifier{"missing@example.com"},
+		EmailIdentifier{"invalid"},
+		ProviderIdentifier{ProviderID: "google.com"},
+	)
+
+	result, err := c.GetUsers(ctx, ids)
+	if err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+	if fake.requests != 2 {
+		t.Errorf("got %d requests, want 2", fake.requests)
+	}
+	var got []string
+	for _, u := range result.Users {
+		got = append(got, u.UID)
+	}
+	sort.Strings(got)
+	if len(got) != 123 || got[0] != "user000" || got[122] != "user122" {
+		t.Errorf("got users %v", got)
+	}
+	wantNotFound := []UserIdentifier{UIDIdentifier{"missing"}, EmailIdentifier{"missing@example.com"}}
+	if !reflect.DeepEqual(result.NotFound, wantNotFound) {
+		t.Errorf("got not found %v, want %v", result.NotFound, wantNotFound)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Index != 126 || result.Errors[1].Index != 127 ||
+		!strings.Contains(result.Errors[1].Reason, "provider") {
+		t.Errorf("got errors %+v", result.Errors)
+	}
+}
+
+func TestBulkContract(t *testing.T) {
+	t.Parallel()
+	const projectID = "projectID"
+	fake := newFakeToolkit(t, projectID)
+	for i := 0; i < 150; i++ {
+		uid := fmt.Sprintf("user%03d", i)
+		fake.users[uid] = map[string]interface{}{"localId": uid}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := fake.newClient()
+
+	// Empty inputs give empty results.
+	if result, err := c.ImportUsers(ctx, nil, nil); err != nil || result.SuccessCount != 0 || result.FailureCount != 0 {
+		t.Errorf("ImportUsers with no users: got %+v, %v", result, err)
+	}
+	if result, err := c.DeleteUsers(ctx, nil); err != nil || result.SuccessCount != 0 || result.FailureCount != 0 {
+		t.Errorf("DeleteUsers with no users: got %+v, %v", result, err)
+	}
+	if result, err := c.GetUsers(ctx, nil); err != nil || len(result.Users) != 0 || len(result.NotFound) != 0 {
+		t.Errorf("GetUsers with no identifiers: got %+v, %v", result, err)
+	}
+	if fake.requests != 0 {
+		t.Errorf("got %d requests for empty inputs, want 0", fake.requests)
+	}
+
+	// When a later batch fails, the results of the earlier ones
+	// are returned with the error.
+	var ids []UserIdentifier
+	for i := 0; i < 150; i++ {
+		ids = append(ids, UIDIdentifier{fmt.Sprintf("user%03d", i)})
+	}
+	fake.mu.Lock()
+	fake.failAfter = 1
+	fake.mu.Unlock()
+	result, err := c.GetUsers(ctx, ids)
+	if err == nil {
+		t.Error("GetUsers with failing batch: got nil error")
+	}
+	if result == nil || len(result.Users) != 100 {
+		t.Errorf("GetUsers with failing batch: got %+v, want the first 100 users", result)
+	}
+
+	fake.mu.Lock()
+	fake.requests, fake.failAfter = 0, 1
+	fake.mu.Unlock()
+	users := make([]ImportUserRecord, 1500)
+	for i := range users {
+		users[i].UID = fmt.Sprintf("new%04d", i)
+	}
+	imported, err := c.ImportUsers(ctx, users, nil)
+	if err == nil {
+		t.Error("ImportUsers with failing batch: got nil error")
+	}
+	if imported == nil || imported.SuccessCount != 1000 {
+		t.Errorf("ImportUsers with failing batch: got %+v, want 1000 successes", imported)
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SermoDigital/jose/crypto"
+)
+
+// certCache is the format of the on-disk cert cache.
+type certCache struct {
+	Expires time.Time         `json:"expires"`
+	Keys    map[string]string `json:"keys"` // PEM-encoded public keys by key ID
+}
+
+// cacheFile returns the path of the cache file for v. The file name
+// depends on the issuer, so ID token and session cookie verifiers
+// can share a directory.
+func (v *Verifier) cacheFile() string {
+	sum := sha256.Sum256([]byte(v.issuer))
+	return filepath.Join(v.cacheDir, "firebase-auth-certs-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadCachedCerts loads certs from the cache, if they have not expired.
+// It must be called before fetchCertLoop is started.
+func (v *Verifier) loadCachedCerts() {
+	data, err := ioutil.ReadFile(v.cacheFile())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			v.logger.Warn("auth: could not read cert cache", "err", err)
+		}
+		return
+	}
+	var cache certCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		v.logger.Warn("auth: could not parse cert cache", "err", err)
+		return
+	}
+	if !cache.Expires.After(v.now()) || len(cache.Keys) == 0 {
+		return
+	}
+
+	certs := make(map[string]*rsa.PublicKey, len(cache.Keys))
+	for kid, key := range cache.Keys {
+		pk, err := crypto.ParseRSAPublicKeyFromPEM([]byte(key))
+		if err != nil {
+			v.logger.Warn("auth: could not parse cached cert", "err", err)
+			return
+		}
+		certs[kid] = pk
+	}
+	v.certs = certs
+	close(v.haveCerts)
+}
+
+// saveCachedCerts writes certs to the cache, to be used until expires.
+func (v *Verifier) saveCachedCerts(certs map[string]*rsa.PublicKey, expires time.Time) {
+	cache := certCache{Expires: expires, Keys: make(map[string]string, len(certs))}
+	for kid, pk := range certs {
+		der, err := x509.MarshalPKIXPublicKey(pk)
+		if err != nil {
+			v.logger.Warn("auth: could not encode cert for cache", "err", err)
+			return
+		}
+		cache.Keys[kid] = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		v.logger.Warn("auth: could not encode cert cache", "err", err)
+		return
+	}
+
+	// Write to a temporary file and rename it into place, so that
+	// a concurrently starting process never reads a partial file.
+	filename := v.cacheFile()
+	tmp, err := ioutil.TempFile(v.cacheDir, filepath.Base(filename)+".tmp")
+	if err != nil {
+		v.logger.Warn("auth: could not write cert cache", "err", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		v.logger.Warn("auth: could not write cert cache", "err", err)
+	}
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// failingKeySource is a KeySource that is always unreachable.
+type failingKeySource struct{}
+
+func (failingKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	return nil, 0, errors.New("unreachable")
+}
+
+func TestCacheDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const projectID = "projectID"
+	token := genToken(map[string]interface{}{
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		"iat":     time.Now().Add(-time.Minute).Unix(),
+		"aud":     projectID,
+		"iss":     "https://securetoken.google.com/" + projectID,
+		"sub":     "sub",
+		"user_id": "sub",
+	}, validKeys[0])
+
+	// Populate the cache.
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	first := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys), WithCacheDir(dir))
+	if _, err := first.Verify(ctx, token); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(first.cacheFile()); err == nil {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("cache file was not written: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A new verifier can verify immediately without reaching the key source.
+	shortCtx, shortCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer shortCancel()
+	second := NewVerifier(shortCtx, projectID, nil, WithKeySource(failingKeySource{}), WithCacheDir(dir))
+	if _, err := second.Verify(shortCtx, token); err != nil {
+		t.Errorf("Verify with cached certs: %v", err)
+	}
+
+	// Expired cache entries are ignored.
+	later := time.Now().Add(time.Hour)
+	third := NewVerifier(shortCtx, projectID, nil, WithKeySource(failingKeySource{}), WithCacheDir(dir),
+		WithClock(func() time.Time { return later }))
+	if _, err := third.Verify(shortCtx, token); !errors.Is(err, ErrNoCerts) {
+		t.Errorf("Verify with expired cache: got err %v, want ErrNoCerts", err)
+	}
+}
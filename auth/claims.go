@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoClaim is returned by User.CustomClaim if the token does not
+// contain the requested claim.
+var ErrNoClaim = errors.New("auth: no such claim")
+
+// AuthTime returns the time the user authenticated, as reported
+// by the "auth_time" claim.
+func (u *User) AuthTime() (time.Time, bool) {
+	return claimTime(u.Claims, "auth_time")
+}
+
+// IssuedAt returns the time the token was issued ("iat").
+func (u *User) IssuedAt() (time.Time, bool) {
+	return claimTime(u.Claims, "iat")
+}
+
+// Expires returns the time the token expires ("exp").
+func (u *User) Expires() (time.Time, bool) {
+	return claimTime(u.Claims, "exp")
+}
+
+// PhoneNumber returns the user's phone number, if any.
+func (u *User) PhoneNumber() string {
+	s, _ := u.Claims.Get("phone_number").(string)
+	return s
+}
+
+// Name returns the user's display name, if any.
+func (u *User) Name() string {
+	s, _ := u.Claims.Get("name").(string)
+	return s
+}
+
+// Picture returns the URL of the user's profile picture, if any.
+func (u *User) Picture() string {
+	s, _ := u.Claims.Get("picture").(string)
+	return s
+}
+
+// Identities returns the "firebase.identities" claim, which maps
+// each sign-in provider (e.g. "email" or "google.com") to the
+// user's identifiers with that provider.
+func (u *User) Identities() map[string][]string {
+	raw, _ := u.firebase()["identities"].(map[string]interface{})
+	if len(raw) == 0 {
+		return nil
+	}
+	ids := make(map[string][]string, len(raw))
+	for provider, v := range raw {
+		list, _ := v.([]interface{})
+		for _, id := range list {
+			if s, ok := id.(string); ok {
+				ids[provider] = append(ids[provider], s)
+			}
+		}
+	}
+	return ids
+}
+
+// CustomClaim decodes the claim named key into v, which should be
+// a pointer as with json.Unmarshal. It returns ErrNoClaim if the
+// claim is not present.
+func (u *User) CustomClaim(key string, v interface{}) error {
+	raw, ok := u.Claims[key]
+	if !ok {
+		return ErrNoClaim
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("auth: cannot marshal claim %q: %v", key, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("auth: cannot decode claim %q: %v", key, err)
+	}
+	return nil
+}
+
+// idTokenClaims are the claims Firebase itself puts in ID tokens.
+var idTokenClaims = map[string]bool{
+	"user_id": true, "email": true, "email_verified": true,
+	"phone_number": true, "name": true, "picture": true,
+	"sign_in_provider": true, "provider_id": true,
+}
+
+// CustomClaims returns the claims in the token that were not set by
+// Firebase itself, such as those set with AdminClient.SetCustomUserClaims.
+// The returned map is a copy; it is empty if there are no custom claims.
+func (u *User) CustomClaims() map[string]interface{} {
+	custom := make(map[string]interface{})
+	for key, value := range u.Claims {
+		if !reservedClaims[key] && !idTokenClaims[key] {
+			custom[key] = value
+		}
+	}
+	return custom
+}
+
+// firebase returns the "firebase" claim object, or nil.
+func (u *User) firebase() map[string]interface{} {
+	m, _ := u.Claims.Get("firebase").(map[string]interface{})
+	return m
+}
+
+// claimTime parses a claim holding seconds since the UNIX epoch.
+func claimTime(claims map[string]interface{}, key string) (time.Time, bool) {
+	switch v := claims[key].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case int64:
+		return time.Unix(v, 0), true
+	case int:
+		return time.Unix(int64(v), 0), true
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(n, 0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUserClaims(t *testing.T) {
+	now := time.Now()
+	authTime := now.Add(-1 * time.Hour).Unix()
+	past := now.Add(-1 * time.Minute).Unix()
+	future := now.Add(1 * time.Minute).Unix()
+	const projectID = "projectID"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	verifier := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys))
+
+	token := genToken(map[string]interface{}{
+		"exp":          future,
+		"iat":          past,
+		"auth_time":    authTime,
+		"aud":          projectID,
+		"iss":          "https://securetoken.google.com/" + projectID,
+		"sub":          "sub",
+		"user_id":      "sub",
+		"name":         "Jane Doe",
+		"picture":      "https://example.com/jane.png",
+		"phone_number": "+15555550100",
+		"roles":        []string{"admin", "editor"},
+		"org":          map[string]interface{}{"id": 42, "name": "Acme"},
+		"firebase": map[string]interface{}{
+			"sign_in_provider": "google.com",
+			"identities": map[string]interface{}{
+				"google.com": []string{"1234"},
+				"email":      []string{"jane@example.com"},
+			},
+		},
+	}, validKeys[0])
+
+	user, err := verifier.Verify(ctx, token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	if got, ok := user.AuthTime(); !ok || got.Unix() != authTime {
+		t.Errorf("AuthTime: got %v, %v, want %v", got, ok, authTime)
+	}
+	if got, ok := user.IssuedAt(); !ok || got.Unix() != past {
+		t.Errorf("IssuedAt: got %v, %v, want %v", got, ok, past)
+	}
+	if got, ok := user.Expires(); !ok || got.Unix() != future {
+		t.Errorf("Expires: got %v, %v, want %v", got, ok, future)
+	}
+	if got, want := user.Name(), "Jane Doe"; got != want {
+		t.Errorf("Name: got %q, want %q", got, want)
+	}
+	if got, want := user.Picture(), "https://example.com/jane.png"; got != want {
+		t.Errorf("Picture: got %q, want %q", got, want)
+	}
+	if got, want := user.PhoneNumber(), "+15555550100"; got != want {
+		t.Errorf("PhoneNumber: got %q, want %q", got, want)
+	}
+	if got, want := user.SignInProvider, "google.com"; got != want {
+		t.Errorf("SignInProvider: got %q, want %q", got, want)
+	}
+
+	wantIDs := map[string][]string{
+		"google.com": {"1234"},
+		"email":      {"jane@example.com"},
+	}
+	if got := user.Identities(); !reflect.DeepEqual(got, wantIDs) {
+		t.Errorf("Identities: got %v, want %v", got, wantIDs)
+	}
+
+	var roles []string
+	if err := user.CustomClaim("roles", &roles); err != nil {
+		t.Errorf("CustomClaim(roles): %v", err)
+	} else if want := []string{"admin", "editor"}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("CustomClaim(roles): got %v, want %v", roles, want)
+	}
+
+	var org struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := user.CustomClaim("org", &org); err != nil {
+		t.Errorf("CustomClaim(org): %v", err)
+	} else if org.ID != 42 || org.Name != "Acme" {
+		t.Errorf("CustomClaim(org): got %+v", org)
+	}
+
+	custom := user.CustomClaims()
+	if len(custom) != 2 || custom["roles"] == nil || custom["org"] == nil {
+		t.Errorf("CustomClaims: got %v, want roles and org", custom)
+	}
+
+	var missing string
+	if err := user.CustomClaim("missing", &missing); err != ErrNoClaim {
+		t.Errorf("CustomClaim(missing): got err %v, want ErrNoClaim", err)
+	}
+
+	// Claims Firebase sets at the top level of some tokens,
+	// such as those of anonymous users, are not custom claims.
+	anonymous := genToken(map[string]interface{}{
+		"exp":              future,
+		"iat":              past,
+		"aud":              projectID,
+		"iss":              "https://securetoken.google.com/" + projectID,
+		"sub":              "anon",
+		"user_id":          "anon",
+		"provider_id":      "anonymous",
+		"sign_in_provider": "anonymous",
+		"firebase": map[string]interface{}{
+			"sign_in_provider": "anonymous",
+		},
+	}, validKeys[0])
+	if user, err := verifier.Verify(ctx, anonymous); err != nil {
+		t.Errorf("Verify(anonymous): %v", err)
+	} else if custom := user.CustomClaims(); len(custom) != 0 {
+		t.Errorf("CustomClaims(anonymous): got %v, want none", custom)
+	}
+}
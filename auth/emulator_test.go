@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// genEmulatorToken returns an unsigned token like the ones
+// issued by the Auth Emulator.
+func genEmulatorToken(payload map[string]interface{}) []byte {
+	header, _ := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	body, _ := json.Marshal(payload)
+	enc := base64.RawURLEncoding
+	return []byte(enc.EncodeToString(header) + "." + enc.EncodeToString(body) + ".")
+}
+
+func TestEmulator(t *testing.T) {
+	const projectID = "demo-project"
+	past := time.Now().Add(-1 * time.Minute).Unix()
+	future := time.Now().Add(1 * time.Minute).Unix()
+	payload := map[string]interface{}{
+		"exp":     future,
+		"iat":     past,
+		"aud":     projectID,
+		"iss":     "https://securetoken.google.com/" + projectID,
+		"sub":     "sub",
+		"user_id": "sub",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	emulated := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys), WithEmulator(true))
+	if u, err := emulated.Verify(ctx, genEmulatorToken(payload)); err != nil {
+		t.Errorf("emulator token: got err %v", err)
+	} else if u.ID != "sub" {
+		t.Errorf("emulator token: got user ID %q", u.ID)
+	}
+
+	// Claims are still checked.
+	payload["aud"] = "other-project"
+	if _, err := emulated.Verify(ctx, genEmulatorToken(payload)); !errors.Is(err, ErrWrongAudience) {
+		t.Errorf("emulator token for other project: got err %v, want ErrWrongAudience", err)
+	}
+	payload["aud"] = projectID
+
+	// Signed tokens are rejected, since the emulator never issues them.
+	if _, err := emulated.Verify(ctx, genToken(payload, validKeys[0])); !errors.Is(err, ErrBadAlgorithm) {
+		t.Errorf("signed token: got err %v, want ErrBadAlgorithm", err)
+	}
+
+	// Unsigned tokens are rejected outside emulator mode.
+	prod := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys), WithEmulator(false))
+	if _, err := prod.Verify(ctx, genEmulatorToken(payload)); !errors.Is(err, ErrBadAlgorithm) {
+		t.Errorf("emulator token outside emulator mode: got err %v, want ErrBadAlgorithm", err)
+	}
+}
+
+func TestEmulatorEnv(t *testing.T) {
+	defer os.Unsetenv(emulatorHostEnv)
+	os.Setenv(emulatorHostEnv, "localhost:9099")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if v := NewVerifier(ctx, "demo-project", nil, WithKeySource(testKeys)); !v.emulator {
+		t.Errorf("got emulator == false with %s set for a demo project", emulatorHostEnv)
+	}
+	if v := NewVerifier(ctx, "demo-project", nil, WithKeySource(testKeys), WithEmulator(false)); v.emulator {
+		t.Errorf("got emulator == true with WithEmulator(false)")
+	}
+}
+
+func TestEmulatorEnvIgnoredInProduction(t *testing.T) {
+	defer os.Unsetenv(emulatorHostEnv)
+	os.Setenv(emulatorHostEnv, "localhost:9099")
+
+	const projectID = "prod-project"
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var buf syncBuffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError}))
+	v := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys), WithLogger(logger))
+	defer v.Close()
+	if v.emulator {
+		t.Fatalf("got emulator == true with %s set for a production project", emulatorHostEnv)
+	}
+	if !strings.Contains(buf.String(), "level=ERROR") || !strings.Contains(buf.String(), emulatorHostEnv) {
+		t.Errorf("got log %q, want an error about %s", buf.String(), emulatorHostEnv)
+	}
+
+	// Unsigned tokens are still rejected, and signed ones verified.
+	payload := map[string]interface{}{
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		"iat":     time.Now().Add(-time.Minute).Unix(),
+		"aud":     projectID,
+		"iss":     "https://securetoken.google.com/" + projectID,
+		"sub":     "sub",
+		"user_id": "sub",
+	}
+	if _, err := v.Verify(ctx, genEmulatorToken(payload)); !errors.Is(err, ErrBadAlgorithm) {
+		t.Errorf("emulator token: got err %v, want ErrBadAlgorithm", err)
+	}
+	if _, err := v.Verify(ctx, genToken(payload, validKeys[0])); err != nil {
+		t.Errorf("signed token: got err %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Reason describes why a token failed verification.
+type Reason int
+
+const (
+	// ReasonMalformed means the token could not be parsed or is
+	// missing required fields.
+	ReasonMalformed Reason = iota + 1
+
+	// ReasonBadAlgorithm means the token is not signed with RS256.
+	ReasonBadAlgorithm
+
+	// ReasonUnknownKeyID means the token was signed with a key
+	// that is not among the current public keys.
+	ReasonUnknownKeyID
+
+	// ReasonBadSignature means the token's signature is invalid.
+	ReasonBadSignature
+
+	// ReasonExpired means the token's exp is in the past.
+	ReasonExpired
+
+	// ReasonNotYetValid means the token's iat is in the future.
+	ReasonNotYetValid
+
+	// ReasonWrongAudience means the token was issued for another project.
+	ReasonWrongAudience
+
+	// ReasonWrongIssuer means the token has an unexpected issuer.
+	ReasonWrongIssuer
+
+	// ReasonInvalidSubject means the token's sub or user_id
+	// is empty or inconsistent.
+	ReasonInvalidSubject
+
+	// ReasonNoCerts means no public keys were available to verify
+	// the token before the context was done.
+	ReasonNoCerts
+
+	// ReasonRevoked means the user's tokens were revoked after
+	// the token was issued.
+	ReasonRevoked
+
+	// ReasonWrongTenant means the token belongs to a tenant
+	// that is not allowed.
+	ReasonWrongTenant
+)
+
+var reasonNames = map[Reason]string{
+	ReasonMalformed:      "malformed",
+	ReasonBadAlgorithm:   "bad algorithm",
+	ReasonUnknownKeyID:   "unknown key ID",
+	ReasonBadSignature:   "bad signature",
+	ReasonExpired:        "expired",
+	ReasonNotYetValid:    "not yet valid",
+	ReasonWrongAudience:  "wrong audience",
+	ReasonWrongIssuer:    "wrong issuer",
+	ReasonInvalidSubject: "invalid subject",
+	ReasonNoCerts:        "no certificates",
+	ReasonRevoked:        "revoked",
+	ReasonWrongTenant:    "wrong tenant",
+}
+
+func (r Reason) String() string {
+	if name, ok := reasonNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Reason(%d)", int(r))
+}
+
+// VerifyError is returned when a token fails verification.
+//
+// Use errors.As to inspect the Reason, or errors.Is to compare
+// against one of the Err* values below, which match any
+// VerifyError with the same Reason:
+//
+//	if errors.Is(err, auth.ErrExpired) {
+//		// ask the client to refresh its token
+//	}
+type VerifyError struct {
+	// Reason describes why verification failed.
+	Reason Reason
+
+	// Err is the underlying error, if any.
+	Err error
+
+	msg string
+}
+
+func (err *VerifyError) Error() string {
+	if err.msg == "" {
+		return "auth: " + err.Reason.String()
+	}
+	return err.msg
+}
+
+func (err *VerifyError) Unwrap() error {
+	return err.Err
+}
+
+// Is reports whether target is a *VerifyError with the same Reason.
+func (err *VerifyError) Is(target error) bool {
+	t, ok := target.(*VerifyError)
+	return ok && t.Reason == err.Reason
+}
+
+// reasonOf returns the Reason for err, which was returned by Verify,
+// or zero if err is nil.
+func reasonOf(err error) Reason {
+	if err == nil {
+		return 0
+	}
+	var verr *VerifyError
+	if errors.As(err, &verr) {
+		return verr.Reason
+	}
+	return ReasonMalformed
+}
+
+// verifyErrorf returns a *VerifyError with the given reason
+// and a message formatted as with fmt.Sprintf.
+func verifyErrorf(reason Reason, format string, args ...interface{}) *VerifyError {
+	return &VerifyError{Reason: reason, msg: fmt.Sprintf(format, args...)}
+}
+
+// Sentinel errors for use with errors.Is.
+var (
+	ErrMalformed      = &VerifyError{Reason: ReasonMalformed, msg: "auth: malformed token"}
+	ErrBadAlgorithm   = &VerifyError{Reason: ReasonBadAlgorithm, msg: "auth: unsupported signing algorithm"}
+	ErrUnknownKeyID   = &VerifyError{Reason: ReasonUnknownKeyID, msg: "auth: unknown kid"}
+	ErrBadSignature   = &VerifyError{Reason: ReasonBadSignature, msg: "auth: verification failure"}
+	ErrExpired        = &VerifyError{Reason: ReasonExpired, msg: "auth: expired token"}
+	ErrNotYetValid    = &VerifyError{Reason: ReasonNotYetValid, msg: "auth: token issued in the future"}
+	ErrWrongAudience  = &VerifyError{Reason: ReasonWrongAudience, msg: "auth: unexpected project ID"}
+	ErrWrongIssuer    = &VerifyError{Reason: ReasonWrongIssuer, msg: "auth: unexpected issuer"}
+	ErrInvalidSubject = &VerifyError{Reason: ReasonInvalidSubject, msg: "auth: invalid sub or user_id"}
+	ErrNoCerts        = &VerifyError{Reason: ReasonNoCerts, msg: "auth: no certificates available"}
+	ErrWrongTenant    = &VerifyError{Reason: ReasonWrongTenant, msg: "auth: unexpected tenant"}
+
+	// ErrTokenRevoked is returned by Verifier.VerifyAndCheckRevoked if the
+	// token was issued before the user's tokens were revoked.
+	ErrTokenRevoked = &VerifyError{Reason: ReasonRevoked, msg: "auth: token has been revoked"}
+)
@@ -0,0 +1,319 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// maxImportUsers is the most users imported in a single request.
+const maxImportUsers = 1000
+
+// HashAlgorithm is a password hashing algorithm supported by
+// AdminClient.ImportUsers.
+//
+// For the parameters each algorithm uses, see the documentation at:
+// https://firebase.google.com/docs/auth/admin/import-users
+type HashAlgorithm string
+
+const (
+	HMACSHA512     HashAlgorithm = "HMAC_SHA512"
+	HMACSHA256     HashAlgorithm = "HMAC_SHA256"
+	HMACSHA1       HashAlgorithm = "HMAC_SHA1"
+	HMACMD5        HashAlgorithm = "HMAC_MD5"
+	MD5            HashAlgorithm = "MD5"
+	SHA1           HashAlgorithm = "SHA1"
+	SHA256         HashAlgorithm = "SHA256"
+	SHA512         HashAlgorithm = "SHA512"
+	PBKDFSHA1      HashAlgorithm = "PBKDF_SHA1"
+	PBKDF2SHA256   HashAlgorithm = "PBKDF2_SHA256"
+	Scrypt         HashAlgorithm = "SCRYPT" // Firebase's modified scrypt
+	StandardScrypt HashAlgorithm = "STANDARD_SCRYPT"
+	Bcrypt         HashAlgorithm = "BCRYPT"
+)
+
+// HashInputOrder is the order in which the salt and the password
+// were combined for hashing, for the HMAC, MD5 and SHA algorithms.
+type HashInputOrder string
+
+const (
+	// SaltFirst means the salt was prepended to the password.
+	SaltFirst HashInputOrder = "SALT_AND_PASSWORD"
+
+	// PasswordFirst means the salt was appended to the password.
+	PasswordFirst HashInputOrder = "PASSWORD_AND_SALT"
+)
+
+// HashOptions describes how the password hashes of imported users
+// were computed. All users in an import must share the same options.
+type HashOptions struct {
+	Algorithm HashAlgorithm
+
+	// Key is the signer key, for the HMAC algorithms and Scrypt.
+	Key []byte
+
+	// SaltSeparator is appended to the salt, for Scrypt.
+	SaltSeparator []byte
+
+	// Rounds is the number of hashing rounds, for MD5 (0 to 8192),
+	// the SHA algorithms (1 to 8192), the PBKDF algorithms
+	// (0 to 120000) and Scrypt (1 to 8).
+	Rounds int
+
+	// MemoryCost is the memory cost, for Scrypt (1 to 14) and
+	// StandardScrypt (the CPU/memory cost parameter N).
+	MemoryCost int
+
+	// Parallelization, BlockSize and DerivedKeyLength are the
+	// remaining parameters of StandardScrypt.
+	Parallelization  int
+	BlockSize        int
+	DerivedKeyLength int
+
+	// InputOrder is the order of the salt and password, for the
+	// HMAC, MD5 and SHA algorithms. If empty, the server's default
+	// is used.
+	InputOrder HashInputOrder
+}
+
+// params validates the options and returns them as
+// accounts:batchCreate request parameters.
+func (h *HashOptions) params() (map[string]interface{}, error) {
+	p := map[string]interface{}{"hashAlgorithm": h.Algorithm}
+	checkRounds := func(min, max int) error {
+		if h.Rounds < min || h.Rounds > max {
+			return fmt.Errorf("auth: %s rounds must be between %d and %d, got %d", h.Algorithm, min, max, h.Rounds)
+		}
+		p["rounds"] = h.Rounds
+		return nil
+	}
+	requireKey := func() error {
+		if len(h.Key) == 0 {
+			return fmt.Errorf("auth: %s requires a key", h.Algorithm)
+		}
+		p["signerKey"] = base64.RawURLEncoding.EncodeToString(h.Key)
+		return nil
+	}
+
+	var err error
+	switch h.Algorithm {
+	case HMACSHA512, HMACSHA256, HMACSHA1, HMACMD5:
+		err = requireKey()
+	case MD5:
+		err = checkRounds(0, 8192)
+	case SHA1, SHA256, SHA512:
+		err = checkRounds(1, 8192)
+	case PBKDFSHA1, PBKDF2SHA256:
+		err = checkRounds(0, 120000)
+	case Scrypt:
+		if err = requireKey(); err == nil {
+			err = checkRounds(1, 8)
+		}
+		if err == nil && (h.MemoryCost < 1 || h.MemoryCost > 14) {
+			err = fmt.Errorf("auth: %s memory cost must be between 1 and 14, got %d", h.Algorithm, h.MemoryCost)
+		}
+		p["memoryCost"] = h.MemoryCost
+		if len(h.SaltSeparator) > 0 {
+			p["saltSeparator"] = base64.RawURLEncoding.EncodeToString(h.SaltSeparator)
+		}
+	case StandardScrypt:
+		if h.MemoryCost <= 0 || h.Parallelization <= 0 || h.BlockSize <= 0 || h.DerivedKeyLength <= 0 {
+			err = fmt.Errorf("auth: %s requires a positive memory cost, parallelization, block size and derived key length", h.Algorithm)
+		}
+		p["cpuMemCost"] = h.MemoryCost
+		p["parallelization"] = h.Parallelization
+		p["blockSize"] = h.BlockSize
+		p["dkLen"] = h.DerivedKeyLength
+	case Bcrypt:
+	case "":
+		return nil, errors.New("auth: no hash algorithm")
+	default:
+		return nil, fmt.Errorf("auth: unknown hash algorithm %q", h.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if h.InputOrder != "" {
+		switch h.Algorithm {
+		case HMACSHA512, HMACSHA256, HMACSHA1, HMACMD5, MD5, SHA1, SHA256, SHA512:
+			p["passwordHashOrder"] = h.InputOrder
+		default:
+			return nil, fmt.Errorf("auth: %s does not take an input order", h.Algorithm)
+		}
+	}
+	return p, nil
+}
+
+// ImportUserRecord is a user to import with AdminClient.ImportUsers.
+// Only UID is required.
+type ImportUserRecord struct {
+	UID           string
+	Email         string
+	EmailVerified bool
+	PhoneNumber   string
+	DisplayName   string
+	PhotoURL      string
+	Disabled      bool
+
+	// PasswordHash and PasswordSalt are the user's password hash
+	// and salt, computed as described by the HashOptions.
+	PasswordHash []byte
+	PasswordSalt []byte
+
+	CustomClaims map[string]interface{}
+	ProviderData []ProviderInfo
+
+	// Metadata sets the creation and last sign-in times of the
+	// account; LastRefreshTime is ignored.
+	Metadata UserMetadata
+}
+
+// importUser is an ImportUserRecord in an accounts:batchCreate request.
+type importUser struct {
+	LocalID          string               `json:"localId"`
+	Email            string               `json:"email,omitempty"`
+	EmailVerified    bool                 `json:"emailVerified,omitempty"`
+	PhoneNumber      string               `json:"phoneNumber,omitempty"`
+	DisplayName      string               `json:"displayName,omitempty"`
+	PhotoURL         string               `json:"photoUrl,omitempty"`
+	Disabled         bool                 `json:"disabled,omitempty"`
+	PasswordHash     string               `json:"passwordHash,omitempty"`
+	Salt             string               `json:"salt,omitempty"`
+	CustomAttributes string               `json:"customAttributes,omitempty"`
+	CreatedAt        int64                `json:"createdAt,omitempty,string"`
+	LastLoginAt      int64                `json:"lastLoginAt,omitempty,string"`
+	ProviderUserInfo []importUserProvider `json:"providerUserInfo,omitempty"`
+}
+
+type importUserProvider struct {
+	ProviderID  string `json:"providerId"`
+	RawID       string `json:"rawId"`
+	Email       string `json:"email,omitempty"`
+	PhoneNumber string `json:"phoneNumber,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	PhotoURL    string `json:"photoUrl,omitempty"`
+}
+
+// request validates the user and returns it as it appears
+// in an accounts:batchCreate request.
+func (u *ImportUserRecord) request() (*importUser, error) {
+	if err := validateUID(u.UID); err != nil {
+		return nil, err
+	}
+	params := make(map[string]interface{})
+	if u.Email != "" {
+		params["email"] = u.Email
+	}
+	if u.PhoneNumber != "" {
+		params["phoneNumber"] = u.PhoneNumber
+	}
+	if err := validateUserParams(params); err != nil {
+		return nil, err
+	}
+	r := &importUser{
+		LocalID:       u.UID,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		PhoneNumber:   u.PhoneNumber,
+		DisplayName:   u.DisplayName,
+		PhotoURL:      u.PhotoURL,
+		Disabled:      u.Disabled,
+		PasswordHash:  base64.RawURLEncoding.EncodeToString(u.PasswordHash),
+		Salt:          base64.RawURLEncoding.EncodeToString(u.PasswordSalt),
+	}
+	if len(u.CustomClaims) > 0 {
+		attrs, err := marshalCustomClaims(u.CustomClaims)
+		if err != nil {
+			return nil, err
+		}
+		r.CustomAttributes = attrs
+	}
+	if !u.Metadata.CreationTime.IsZero() {
+		r.CreatedAt = u.Metadata.CreationTime.UnixNano() / 1e6
+	}
+	if !u.Metadata.LastSignInTime.IsZero() {
+		r.LastLoginAt = u.Metadata.LastSignInTime.UnixNano() / 1e6
+	}
+	for _, p := range u.ProviderData {
+		if p.ProviderID == "" || p.UID == "" {
+			return nil, errors.New("auth: provider data requires a provider ID and UID")
+		}
+		r.ProviderUserInfo = append(r.ProviderUserInfo, importUserProvider{
+			ProviderID:  p.ProviderID,
+			RawID:       p.UID,
+			Email:       p.Email,
+			PhoneNumber: p.PhoneNumber,
+			DisplayName: p.DisplayName,
+			PhotoURL:    p.PhotoURL,
+		})
+	}
+	return r, nil
+}
+
+// ImportResult reports the outcome of AdminClient.ImportUsers.
+type ImportResult struct {
+	SuccessCount int
+	FailureCount int
+
+	// Errors describes each user that was not imported,
+	// in the order of the users.
+	Errors []ItemError
+}
+
+// ImportUsers imports users, with their password hashes computed as
+// described by hash, which is only needed if some of the users have
+// a PasswordHash. Users are imported in batches of 1000.
+//
+// Users that are invalid or that the server rejects are reported in
+// the result and do not prevent the others from being imported. If a
+// batch fails as a whole, ImportUsers returns the result of the
+// previous batches along with the error.
+//
+// For more information, see the documentation at:
+// https://firebase.google.com/docs/auth/admin/import-users
+func (c *AdminClient) ImportUsers(ctx context.Context, users []ImportUserRecord, hash *HashOptions) (*ImportResult, error) {
+	var hashParams map[string]interface{}
+	if hash != nil {
+		var err error
+		if hashParams, err = hash.params(); err != nil {
+			return nil, err
+		}
+	}
+
+	var batch []*importUser
+	add := func(i int) error {
+		u := &users[i]
+		if len(u.PasswordHash) > 0 && hashParams == nil {
+			return errors.New("auth: password hash given without hash options")
+		}
+		r, err := u.request()
+		if err != nil {
+			return err
+		}
+		batch = append(batch, r)
+		return nil
+	}
+	send := func() ([]batchItemError, error) {
+		req := make(map[string]interface{}, len(hashParams)+1)
+		for k, v := range hashParams {
+			req[k] = v
+		}
+		req["users"] = batch
+		batch = nil
+		var resp struct {
+			Error []batchItemError `json:"error"`
+		}
+		err := c.toolkit.post(ctx, "/accounts:batchCreate", req, &resp)
+		return resp.Error, err
+	}
+
+	var bulk bulkResult
+	err := bulk.run(len(users), maxImportUsers, add, send)
+	return &ImportResult{
+		SuccessCount: bulk.successes,
+		FailureCount: len(bulk.errors),
+		Errors:       bulk.errors,
+	}, err
+}
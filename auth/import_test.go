@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestImportUsers(t *testing.T) {
+	t.Parallel()
+	const projectID = "projectID"
+	fake := newFakeToolkit(t, projectID)
+	fake.users["user0007"] = map[string]interface{}{"localId": "user0007"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := fake.newClient()
+
+	users := make([]ImportUserRecord, 1500)
+	for i := range users {
+		users[i] = ImportUserRecord{
+			UID:          fmt.Sprintf("user%04d", i),
+			Email:        fmt.Sprintf("user%04d@example.com", i),
+			PasswordHash: []byte("hash"),
+			PasswordSalt: []byte("salt"),
+		}
+	}
+	users[3].Email = "invalid"
+	users[1200].CustomClaims = map[string]interface{}{"iss": "reserved"}
+	users[1499].CustomClaims = map[string]interface{}{"admin": true}
+	users[1499].Metadata.CreationTime = time.Unix(1500000000, 0)
+
+	hash := &HashOptions{Algorithm: HMACSHA256, Key: []byte("key"), InputOrder: SaltFirst}
+	result, err := c.ImportUsers(ctx, users, hash)
+	if err != nil {
+		t.Fatalf("ImportUsers: %v", err)
+	}
+	if fake.requests != 2 {
+		t.Errorf("got %d requests, want 2", fake.requests)
+	}
+	if result.SuccessCount != 1497 || result.FailureCount != 3 {
+		t.Errorf("got %d successes and %d failures, want 1497 and 3", result.SuccessCount, result.FailureCount)
+	}
+	var indexes []int
+	for _, e := range result.Errors {
+		indexes = append(indexes, e.Index)
+	}
+	if want := []int{3, 7, 1200}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("got errors for users %v, want %v (%v)", indexes, want, result.Errors)
+	}
+	if got := result.Errors[1].Reason; got != "DUPLICATE_LOCAL_ID" {
+		t.Errorf("got reason %q for duplicate user", got)
+	}
+
+	if got := fake.lastImport["hashAlgorithm"]; got != "HMAC_SHA256" {
+		t.Errorf("got hashAlgorithm %v", got)
+	}
+	if got := fake.lastImport["signerKey"]; got != "a2V5" {
+		t.Errorf("got signerKey %v", got)
+	}
+	if got := fake.lastImport["passwordHashOrder"]; got != "SALT_AND_PASSWORD" {
+		t.Errorf("got passwordHashOrder %v", got)
+	}
+	if u, err := c.GetUser(ctx, "user1499"); err != nil {
+		t.Errorf("GetUser: %v", err)
+	} else if u.CustomClaims["admin"] != true || !u.Metadata.CreationTime.Equal(time.Unix(1500000000, 0)) {
+		t.Errorf("got imported user %+v", u)
+	}
+
+	// Password hashes require hash options.
+	result, err = c.ImportUsers(ctx, []ImportUserRecord{{UID: "nohash", PasswordHash: []byte("hash")}}, nil)
+	if err != nil {
+		t.Errorf("ImportUsers without hash options: %v", err)
+	} else if result.FailureCount != 1 {
+		t.Errorf("ImportUsers without hash options: got %+v", result)
+	}
+
+	for _, hash := range []*HashOptions{
+		{},
+		{Algorithm: "ROT13"},
+		{Algorithm: HMACSHA1},
+		{Algorithm: SHA256, Rounds: 0},
+		{Algorithm: PBKDF2SHA256, Rounds: 200000},
+		{Algorithm: Scrypt, Key: []byte("key"), Rounds: 8, MemoryCost: 15},
+		{Algorithm: StandardScrypt, MemoryCost: 1024},
+		{Algorithm: Bcrypt, InputOrder: PasswordFirst},
+	} {
+		if _, err := c.ImportUsers(ctx, users[:1], hash); err == nil {
+			t.Errorf("hash options %+v: got nil error", hash)
+		}
+	}
+	for _, hash := range []*HashOptions{
+		{Algorithm: Bcrypt},
+		{Algorithm: Scrypt, Key: []byte("key"), SaltSeparator: []byte("sep"), Rounds: 8, MemoryCost: 14},
+		{Algorithm: StandardScrypt, MemoryCost: 1024, Parallelization: 16, BlockSize: 8, DerivedKeyLength: 64},
+		{Algorithm: PBKDFSHA1, Rounds: 1000},
+		{Algorithm: MD5, InputOrder: PasswordFirst},
+	} {
+		if _, err := hash.params(); err != nil {
+			t.Errorf("hash options %+v: got err %v", hash, err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SermoDigital/jose/crypto"
+)
+
+// idTokenCertificateURL is where Google publishes the public keys
+// used to sign ID tokens.
+const idTokenCertificateURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+
+// IDTokenJWKSURL is where Google publishes the ID token public keys
+// as a JSON Web Key Set, for use with JWKSKeySource or URLKeySource.
+const IDTokenJWKSURL = "https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com"
+
+// defaultKeyExpiry is how long keys are used before being refreshed
+// when the source gives no better indication.
+const defaultKeyExpiry = 10 * time.Minute
+
+// A KeySource provides the public keys that tokens are signed with.
+//
+// Keys returns the current keys by key ID, and how long they may be
+// used before Keys should be called again. The Verifier calls Keys
+// from a single goroutine.
+type KeySource interface {
+	Keys(ctx context.Context) (keys map[string]*rsa.PublicKey, expiry time.Duration, err error)
+}
+
+// A LoggingKeySource is a KeySource that logs, such as the ones
+// returned by URLKeySource. A Verifier uses the KeySource returned
+// by KeySourceWithLogger instead, so that it logs through the
+// Verifier's logger (see WithLogger).
+type LoggingKeySource interface {
+	KeySource
+
+	// KeySourceWithLogger returns a KeySource like this one
+	// that logs to logger.
+	KeySourceWithLogger(logger *slog.Logger) KeySource
+}
+
+// X509KeySource returns a KeySource that fetches keys from url, which
+// serves a JSON object mapping key IDs to PEM-encoded X.509 certificates,
+// as Google does for Firebase ID tokens and session cookies.
+// Keys expire according to the response's Cache-Control max-age.
+// If client is nil, http.DefaultClient is used.
+func X509KeySource(url string, client *http.Client) KeySource {
+	return newURLKeySource(url, client, parseX509Keys)
+}
+
+// JWKSKeySource returns a KeySource that fetches keys from url, which
+// serves a JSON Web Key Set (RFC 7517). Only RSA keys for signing
+// with RS256 are used; keys with another "alg" or "use" are ignored.
+// Keys expire according to the response's Cache-Control max-age.
+// If client is nil, http.DefaultClient is used.
+func JWKSKeySource(url string, client *http.Client) KeySource {
+	return newURLKeySource(url, client, parseJWKS)
+}
+
+// URLKeySource is like X509KeySource and JWKSKeySource, but detects
+// the format of the keys served by url.
+func URLKeySource(url string, client *http.Client) KeySource {
+	return newURLKeySource(url, client, parseKeys)
+}
+
+// StaticKeySource returns a KeySource that always returns keys.
+func StaticKeySource(keys map[string]*rsa.PublicKey) KeySource {
+	copied := make(map[string]*rsa.PublicKey, len(keys))
+	for kid, key := range keys {
+		copied[kid] = key
+	}
+	return staticKeySource(copied)
+}
+
+// FileKeySource returns a KeySource that reads keys from the named file,
+// which holds either a JSON Web Key Set or a JSON object mapping key IDs
+// to PEM-encoded X.509 certificates. The file is re-read every ten minutes.
+func FileKeySource(filename string) KeySource {
+	return fileKeySource(filename)
+}
+
+type staticKeySource map[string]*rsa.PublicKey
+
+func (s staticKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	return s, 24 * time.Hour, nil
+}
+
+type fileKeySource string
+
+func (s fileKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	data, err := ioutil.ReadFile(string(s))
+	if err != nil {
+		return nil, 0, err
+	}
+	keys, err := parseKeys(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("auth: %s: %v", s, err)
+	}
+	return keys, defaultKeyExpiry, nil
+}
+
+type urlKeySource struct {
+	url    string
+	client *http.Client
+	parse  func(data []byte) (map[string]*rsa.PublicKey, error)
+	logger *slog.Logger
+}
+
+func newURLKeySource(url string, client *http.Client, parse func([]byte) (map[string]*rsa.PublicKey, error)) *urlKeySource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &urlKeySource{url: url, client: client, parse: parse, logger: slog.Default()}
+}
+
+func (s *urlKeySource) KeySourceWithLogger(logger *slog.Logger) KeySource {
+	copied := *s
+	copied.logger = logger
+	return &copied
+}
+
+func (s *urlKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	req, err := http.NewRequest("GET", s.url, nil)
+	if err != nil {
+		// Should never happen for a well-formed URL
+		panic("auth: internal error: could not create request (invalid URL?): " + err.Error())
+	}
+
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("auth: unexpected status code %d fetching keys", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	keys, err := s.parse(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return keys, maxAge(s.logger, resp.Header), nil
+}
+
+// maxAge parses max-age from the Cache-Control header into
+// a duration, per the spec:
+// https://firebase.google.com/docs/auth/admin/verify-id-tokens
+// An unparseable max-age is logged to logger.
+func maxAge(logger *slog.Logger, header http.Header) time.Duration {
+	cacheControl := header.Get("Cache-Control")
+	parts := strings.Split(cacheControl, ",")
+	expiry := defaultKeyExpiry
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "max-age=") {
+			seconds, err := strconv.Atoi(part[len("max-age="):])
+			if err != nil {
+				logger.Warn("auth: could not parse max-age for JWT certificate expiry, using default",
+					"cache_control", cacheControl, "default", defaultKeyExpiry)
+				break
+			}
+			expiry = time.Duration(seconds) * time.Second
+		}
+	}
+
+	// Sanity check against weird max-age values
+	if expiry < 0 {
+		expiry = defaultKeyExpiry
+	}
+	return expiry
+}
+
+// parseKeys parses either a JSON Web Key Set or a JSON object mapping
+// key IDs to X.509 certificates, depending on which data holds.
+func parseKeys(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err == nil && set.Keys != nil {
+		return parseJWKS(data)
+	}
+	return parseX509Keys(data)
+}
+
+// parseX509Keys parses a JSON object mapping key IDs to
+// PEM-encoded X.509 certificates.
+func parseX509Keys(data []byte) (map[string]*rsa.PublicKey, error) {
+	var certs map[string]string
+	if err := json.Unmarshal(data, &certs); err != nil {
+		return nil, err
+	}
+
+	parsedCerts := make(map[string]*rsa.PublicKey, len(certs))
+	for key, cert := range certs {
+		pk, err := crypto.ParseRSAPublicKeyFromPEM([]byte(cert))
+		if err != nil {
+			return nil, err
+		}
+		parsedCerts[key] = pk
+	}
+	return parsedCerts, nil
+}
+
+// parseJWKS parses the keys in a JSON Web Key Set that can be used to
+// verify RS256 signatures. Keys meant for other algorithms or for
+// encryption are skipped, but it is an error if no keys remain.
+func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		// "alg" and "use" are optional, but if present must
+		// allow RS256 signatures (RFC 7517, sections 4.2 and 4.4).
+		if k.Kty != "RSA" || (k.Alg != "" && k.Alg != "RS256") || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		if k.Kid == "" {
+			return nil, errors.New("auth: JWK has no kid")
+		}
+		if _, ok := keys[k.Kid]; ok {
+			return nil, fmt.Errorf("auth: duplicate JWK kid %s", k.Kid)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) == 0 {
+			return nil, fmt.Errorf("auth: JWK %s: invalid modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("auth: JWK %s: invalid exponent: %v", k.Kid, err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() > 1<<31-1 || exp.Int64() < 3 {
+			return nil, fmt.Errorf("auth: JWK %s: invalid exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("auth: JWKS contains no RS256 signing keys")
+	}
+	return keys, nil
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// jwk returns the JSON Web Key representation of k.
+func jwk(k key) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": k.id,
+		"alg": "RS256",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(k.pk.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.pk.E)).Bytes()),
+	}
+}
+
+func TestKeySources(t *testing.T) {
+	jwksServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var keys []map[string]string
+		for _, k := range validKeys {
+			keys = append(keys, jwk(k))
+		}
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	defer jwksServ.Close()
+
+	dir, err := ioutil.TempDir("", "auth-keysource-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "certs.json")
+	certs := make(map[string]string)
+	for _, k := range validKeys {
+		certs[k.id] = k.cert
+	}
+	data, _ := json.Marshal(certs)
+	if err := ioutil.WriteFile(filename, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	static := make(map[string]*rsa.PublicKey)
+	for _, k := range validKeys {
+		static[k.id] = &k.pk.PublicKey
+	}
+
+	var tests = []struct {
+		Name   string
+		Source KeySource
+		Expiry time.Duration
+	}{
+		{"x509", testKeys, defaultKeyExpiry},
+		{"jwks", JWKSKeySource(jwksServ.URL, nil), time.Hour},
+		{"static", StaticKeySource(static), 24 * time.Hour},
+		{"file", FileKeySource(filename), defaultKeyExpiry},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for _, test := range tests {
+		keys, expiry, err := test.Source.Keys(ctx)
+		if err != nil {
+			t.Errorf("%s: got err %v", test.Name, err)
+			continue
+		}
+		if expiry != test.Expiry {
+			t.Errorf("%s: got expiry %v, want %v", test.Name, expiry, test.Expiry)
+		}
+		if len(keys) != len(validKeys) {
+			t.Errorf("%s: got %d keys, want %d", test.Name, len(keys), len(validKeys))
+		}
+		for _, k := range validKeys {
+			if got := keys[k.id]; got == nil || got.N.Cmp(k.pk.N) != 0 || got.E != k.pk.E {
+				t.Errorf("%s: key %s: got %v, want %v", test.Name, k.id, got, k.pk.PublicKey)
+			}
+		}
+	}
+}
+
+func TestVerifiersWithSeparateKeySources(t *testing.T) {
+	const projectID = "projectID"
+	token := genToken(map[string]interface{}{
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		"iat":     time.Now().Add(-time.Minute).Unix(),
+		"aud":     projectID,
+		"iss":     "https://securetoken.google.com/" + projectID,
+		"sub":     "sub",
+		"user_id": "sub",
+	}, validKeys[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	withKey := NewVerifier(ctx, projectID, nil, WithKeySource(StaticKeySource(map[string]*rsa.PublicKey{
+		validKeys[0].id: &validKeys[0].pk.PublicKey,
+	})))
+	withoutKey := NewVerifier(ctx, projectID, nil, WithKeySource(StaticKeySource(map[string]*rsa.PublicKey{
+		validKeys[1].id: &validKeys[1].pk.PublicKey,
+	})))
+
+	if _, err := withKey.Verify(ctx, token); err != nil {
+		t.Errorf("got err %v", err)
+	}
+	if _, err := withoutKey.Verify(ctx, token); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("got err %v, want ErrUnknownKeyID", err)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	k := validKeys[0]
+	with := func(field, value string) map[string]string {
+		m := jwk(k)
+		m[field] = value
+		return m
+	}
+	encKey := with("use", "enc")
+	encKey["kid"] = "enc"
+
+	var tests = []struct {
+		Keys []map[string]string
+		IDs  []string
+		Err  bool
+	}{
+		0:  {Keys: []map[string]string{jwk(k)}, IDs: []string{k.id}},
+		1:  {Keys: []map[string]string{jwk(k), encKey}, IDs: []string{k.id}},
+		2:  {Keys: []map[string]string{with("alg", "RS512")}, Err: true},
+		3:  {Keys: []map[string]string{with("use", "enc")}, Err: true},
+		4:  {Keys: []map[string]string{with("kty", "EC")}, Err: true},
+		5:  {Keys: []map[string]string{with("kid", "")}, Err: true},
+		6:  {Keys: []map[string]string{with("e", "")}, Err: true},
+		7:  {Keys: []map[string]string{with("n", "!!")}, Err: true},
+		8:  {Keys: []map[string]string{jwk(k), jwk(k)}, Err: true},
+		9:  {Keys: []map[string]string{with("alg", ""), with("use", "")}, Err: true}, // duplicate kid
+		10: {Keys: []map[string]string{}, Err: true},
+	}
+	for i, test := range tests {
+		data, _ := json.Marshal(map[string]interface{}{"keys": test.Keys})
+		keys, err := parseKeys(data)
+		if test.Err {
+			if err == nil {
+				t.Errorf("%d: got err == nil", i)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%d: got err %v", i, err)
+			continue
+		}
+		if len(keys) != len(test.IDs) {
+			t.Errorf("%d: got %d keys, want %d", i, len(keys), len(test.IDs))
+		}
+		for _, id := range test.IDs {
+			if keys[id] == nil {
+				t.Errorf("%d: missing key %s", i, id)
+			}
+		}
+	}
+
+	if _, err := parseJWKS([]byte(`{}`)); err == nil {
+		t.Errorf("no keys field: got err == nil")
+	}
+
+	// The X.509 format is still detected.
+	data, _ := json.Marshal(map[string]string{k.id: k.cert})
+	if keys, err := parseKeys(data); err != nil || keys[k.id] == nil {
+		t.Errorf("x509: got keys %v, err %v", keys, err)
+	}
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrClosed is wrapped by the errors returned from a closed Verifier
+// that has no certificates.
+var ErrClosed = errors.New("auth: verifier closed")
+
+// VerifierStatus describes the state of a Verifier's public keys.
+type VerifierStatus struct {
+	// Ready reports whether the Verifier has keys to verify tokens with.
+	Ready bool
+
+	// KeyIDs lists the IDs of the current keys, sorted.
+	KeyIDs []string
+
+	// LastFetch is the time of the last attempt to fetch keys,
+	// and LastSuccess the time of the last successful one.
+	// Both are zero if keys have not been fetched yet.
+	LastFetch   time.Time
+	LastSuccess time.Time
+
+	// LastError is the error from the last attempt to fetch keys,
+	// or nil if it succeeded.
+	LastError error
+
+	// NextRefresh is when keys will next be fetched.
+	NextRefresh time.Time
+}
+
+// A RefreshEvent describes an attempt to fetch the public keys.
+// See WithRefreshHook.
+type RefreshEvent struct {
+	// Err is the error fetching the keys, or nil on success.
+	Err error
+
+	// Keys is the number of keys fetched.
+	Keys int
+
+	// NextRefresh is when keys will next be fetched.
+	NextRefresh time.Time
+}
+
+// Ready blocks until the Verifier has public keys to verify tokens with,
+// or until ctx is done or the Verifier is closed.
+func (v *Verifier) Ready(ctx context.Context) error {
+	select {
+	case <-v.haveCerts:
+		return nil
+	default:
+	}
+	select {
+	case <-v.haveCerts:
+		return nil
+	case <-v.done:
+		return &VerifyError{Reason: ReasonNoCerts, Err: ErrClosed, msg: "auth: no certificates available: verifier closed"}
+	case <-ctx.Done():
+		return &VerifyError{Reason: ReasonNoCerts, Err: ctx.Err(), msg: fmt.Sprintf("auth: no certificates available: %v", ctx.Err())}
+	}
+}
+
+// Status returns the current state of the Verifier's public keys.
+func (v *Verifier) Status() VerifierStatus {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	st := v.status
+	st.Ready = len(v.certs) > 0 || v.emulator
+	st.KeyIDs = make([]string, 0, len(v.certs))
+	for kid := range v.certs {
+		st.KeyIDs = append(st.KeyIDs, kid)
+	}
+	sort.Strings(st.KeyIDs)
+	return st
+}
+
+// Close stops fetching public keys and waits for the background
+// goroutine to exit. Tokens can still be verified with the keys
+// the Verifier already has, until they are rotated out by Google.
+func (v *Verifier) Close() error {
+	v.cancel()
+	<-v.done
+	return nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLifecycle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	events := make(chan RefreshEvent, 10)
+	v := NewVerifier(ctx, "projectID", nil, WithKeySource(testKeys), WithRefreshHook(func(ev RefreshEvent) {
+		events <- ev
+	}))
+	if err := v.Ready(ctx); err != nil {
+		t.Fatalf("Ready: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Err != nil || ev.Keys != len(validKeys) {
+			t.Errorf("got refresh event %+v", ev)
+		}
+	case <-ctx.Done():
+		t.Fatal("no refresh event")
+	}
+
+	st := v.Status()
+	var wantIDs []string
+	for _, k := range validKeys {
+		wantIDs = append(wantIDs, k.id)
+	}
+	sort.Strings(wantIDs)
+	if !st.Ready || !reflect.DeepEqual(st.KeyIDs, wantIDs) {
+		t.Errorf("got status %+v, want ready with key IDs %v", st, wantIDs)
+	}
+	if st.LastFetch.IsZero() || st.LastSuccess != st.LastFetch || st.LastError != nil {
+		t.Errorf("got status %+v, want a successful fetch", st)
+	}
+	if !st.NextRefresh.After(st.LastFetch) {
+		t.Errorf("got next refresh %v, want after %v", st.NextRefresh, st.LastFetch)
+	}
+
+	v.Close()
+	v.Close() // must be idempotent
+}
+
+func TestLifecycleFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	events := make(chan RefreshEvent, 10)
+	v := NewVerifier(ctx, "projectID", nil, WithKeySource(failingKeySource{}), WithRefreshHook(func(ev RefreshEvent) {
+		events <- ev
+	}))
+
+	select {
+	case ev := <-events:
+		if ev.Err == nil {
+			t.Errorf("got refresh event %+v, want error", ev)
+		}
+	case <-ctx.Done():
+		t.Fatal("no refresh event")
+	}
+	if st := v.Status(); st.Ready || st.LastError == nil || !st.LastSuccess.IsZero() {
+		t.Errorf("got status %+v, want not ready with an error", st)
+	}
+
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+	if err := v.Ready(shortCtx); !errors.Is(err, ErrNoCerts) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Ready: got err %v, want ErrNoCerts wrapping DeadlineExceeded", err)
+	}
+
+	v.Close()
+	if err := v.Ready(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("Ready after Close: got err %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MaxListUsersResults is the largest page size ListUsers accepts.
+const MaxListUsersResults = 1000
+
+// listUsersRetries is how many times a failed page is retried.
+const listUsersRetries = 3
+
+// Done is returned by UserIterator.Next when there are no more users.
+var Done = errors.New("auth: no more users")
+
+// A UserIterator iterates over the users of a project.
+// See AdminClient.ListUsers.
+type UserIterator struct {
+	c        *AdminClient
+	ctx      context.Context
+	pageSize int
+
+	users     []*UserRecord // fetched but not yet returned
+	pageToken string        // of the next page to fetch
+	last      bool          // the last page has been fetched
+	err       error         // returned by every call to Next once set
+}
+
+// ListUsers returns an iterator over the users of the project, in
+// pages of pageSize users, which must be at most MaxListUsersResults.
+// If pageSize is zero, the server's default is used. Iteration starts
+// at pageToken, as returned by UserIterator.PageToken, or at the first
+// user if pageToken is empty.
+//
+// Pages that fail with a transient error are retried a few times.
+// ctx bounds the whole iteration.
+func (c *AdminClient) ListUsers(ctx context.Context, pageSize int, pageToken string) *UserIterator {
+	it := &UserIterator{c: c, ctx: ctx, pageSize: pageSize, pageToken: pageToken}
+	if pageSize < 0 || pageSize > MaxListUsersResults {
+		it.err = fmt.Errorf("auth: page size must be between 0 and %d, got %d", MaxListUsersResults, pageSize)
+	}
+	return it
+}
+
+// Next returns the next user. It returns Done when there are no more
+// users, or another error if they could not be fetched; once it has
+// returned an error, it always returns the same error.
+func (it *UserIterator) Next() (*UserRecord, error) {
+	for len(it.users) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		if it.last {
+			it.err = Done
+			return nil, Done
+		}
+		it.err = it.fetch()
+	}
+	u := it.users[0]
+	it.users = it.users[1:]
+	return u, nil
+}
+
+// PageToken returns the token of the next page to fetch, which can
+// be passed to ListUsers to resume the iteration later. Users that
+// have been fetched but not yet returned by Next are not included
+// in that page. It returns the empty string after the last page.
+func (it *UserIterator) PageToken() string {
+	if it.last {
+		return ""
+	}
+	return it.pageToken
+}
+
+// fetch fetches the next page, retrying transient errors.
+func (it *UserIterator) fetch() error {
+	query := url.Values{}
+	if it.pageSize > 0 {
+		query.Set("maxResults", strconv.Itoa(it.pageSize))
+	}
+	if it.pageToken != "" {
+		query.Set("nextPageToken", it.pageToken)
+	}
+	var resp struct {
+		Users         []userResponse `json:"users"`
+		NextPageToken string         `json:"nextPageToken"`
+	}
+	for attempt := 0; ; attempt++ {
+		if err := it.ctx.Err(); err != nil {
+			return err
+		}
+		err := it.c.toolkit.get(it.ctx, "/accounts:batchGet", query, &resp)
+		if err == nil {
+			break
+		}
+		if attempt == listUsersRetries || !isTransient(err) {
+			return err
+		}
+		timer := time.NewTimer(it.c.backoff.delay(attempt))
+		select {
+		case <-it.ctx.Done():
+			timer.Stop()
+			return it.ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	users := make([]*UserRecord, 0, len(resp.Users))
+	for i := range resp.Users {
+		u, err := resp.Users[i].record()
+		if err != nil {
+			return err
+		}
+		users = append(users, u)
+	}
+	it.users = users
+	it.pageToken = resp.NextPageToken
+	it.last = resp.NextPageToken == ""
+	return nil
+}
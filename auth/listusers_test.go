@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestListUsers(t *testing.T) {
+	t.Parallel()
+	const projectID = "projectID"
+	fake := newFakeToolkit(t, projectID)
+	for i := 0; i < 25; i++ {
+		uid := fmt.Sprintf("user%02d", i)
+		fake.users[uid] = map[string]interface{}{"localId": uid}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := fake.newClient()
+	c.backoff = Backoff{Base: time.Millisecond, Max: time.Millisecond}
+
+	// listAll returns the IDs of the users listed by it.
+	listAll := func(it *UserIterator) ([]string, error) {
+		var uids []string
+		for {
+			u, err := it.Next()
+			if err == Done {
+				return uids, nil
+			} else if err != nil {
+				return uids, err
+			}
+			uids = append(uids, u.UID)
+		}
+	}
+
+	// Transient failures are retried.
+	fake.failures = 2
+	it := c.ListUsers(ctx, 10, "")
+	uids, err := listAll(it)
+	if err != nil {
+		t.Fatalf("listing users: %v", err)
+	}
+	if len(uids) != 25 || uids[0] != "user00" || uids[24] != "user24" {
+		t.Errorf("got users %v", uids)
+	}
+	if fake.requests != 5 {
+		t.Errorf("got %d requests, want 5", fake.requests)
+	}
+	if _, err := it.Next(); err != Done {
+		t.Errorf("Next after Done: got err %v, want Done", err)
+	}
+	if tok := it.PageToken(); tok != "" {
+		t.Errorf("PageToken after last page: got %q", tok)
+	}
+
+	// Iteration can resume from a page token.
+	it = c.ListUsers(ctx, 10, "")
+	for i := 0; i < 10; i++ {
+		it.Next()
+	}
+	uids, err = listAll(c.ListUsers(ctx, 10, it.PageToken()))
+	if err != nil {
+		t.Fatalf("resuming: %v", err)
+	}
+	if len(uids) != 15 || uids[0] != "user10" {
+		t.Errorf("resuming: got users %v", uids)
+	}
+
+	// Persistent failures are returned.
+	fake.failures = listUsersRetries + 1
+	if _, err := c.ListUsers(ctx, 10, "").Next(); err == nil || err == Done {
+		t.Errorf("persistent failure: got err %v", err)
+	}
+	fake.failures = 0
+
+	// Permanent failures are not retried.
+	fake.requests = 0
+	it = c.ListUsers(ctx, 10, "bogus")
+	var apiErr *APIError
+	if _, err := it.Next(); !errors.As(err, &apiErr) || apiErr.Code != "INVALID_PAGE_SELECTION" {
+		t.Errorf("invalid page token: got err %v", err)
+	}
+	if fake.requests != 1 {
+		t.Errorf("invalid page token: got %d requests, want 1", fake.requests)
+	}
+
+	cctx, ccancel := context.WithCancel(ctx)
+	ccancel()
+	if _, err := c.ListUsers(cctx, 10, "").Next(); err != context.Canceled {
+		t.Errorf("cancelled context: got err %v, want context.Canceled", err)
+	}
+	if _, err := c.ListUsers(ctx, MaxListUsersResults+1, "").Next(); err == nil || err == Done {
+		t.Errorf("page size too large: got err %v", err)
+	}
+}
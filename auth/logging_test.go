@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLogger(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=bogus")
+		keyHandler(w, req)
+	}))
+	defer serv.Close()
+
+	var buf syncBuffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	v := NewVerifier(ctx, "projectID", nil, WithKeySource(X509KeySource(serv.URL, nil)), WithLogger(logger))
+	if err := v.Ready(ctx); err != nil {
+		t.Fatalf("Ready: %v", err)
+	}
+	v.Close()
+	if got := buf.String(); !strings.Contains(got, "level=WARN") || !strings.Contains(got, "max-age") {
+		t.Errorf("got log %q, want max-age warning", got)
+	}
+
+	events := make(chan RefreshEvent, 1)
+	v = NewVerifier(ctx, "projectID", nil, WithKeySource(failingKeySource{}), WithLogger(logger),
+		WithRefreshHook(func(ev RefreshEvent) { events <- ev }))
+	<-events
+	v.Close()
+	if got := buf.String(); !strings.Contains(got, "level=ERROR") || !strings.Contains(got, "err=unreachable") {
+		t.Errorf("got log %q, want fetch error", got)
+	}
+}
+
+// loggingKeySource records the logger it was given.
+type loggingKeySource struct {
+	logger *slog.Logger
+}
+
+func (s loggingKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	s.logger.Info("fetching keys")
+	return testKeys.Keys(ctx)
+}
+
+func (s loggingKeySource) KeySourceWithLogger(logger *slog.Logger) KeySource {
+	return loggingKeySource{logger: logger}
+}
+
+func TestLoggingKeySource(t *testing.T) {
+	var buf syncBuffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	v := NewVerifier(ctx, "projectID", nil, WithKeySource(loggingKeySource{}), WithLogger(logger))
+	defer v.Close()
+	if err := v.Ready(ctx); err != nil {
+		t.Fatalf("Ready: %v", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "fetching keys") {
+		t.Errorf("got log %q, want key source message", got)
+	}
+}
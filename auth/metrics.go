@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+// Metrics records a Verifier's activity, for export to a monitoring
+// system. See the metrics/prommetrics and metrics/otelmetrics packages
+// for implementations backed by Prometheus and OpenTelemetry.
+//
+// Methods may be called concurrently and should not block.
+type Metrics interface {
+	// VerifyDone records a call to Verify, VerifyAndCheckRevoked or
+	// TenantVerifier.Verify that took d. The reason is zero if the
+	// token was accepted, and otherwise why it was not. Each call is
+	// recorded once, with its final result.
+	VerifyDone(reason Reason, d time.Duration)
+
+	// RefreshDone records an attempt to fetch the public keys,
+	// which failed if err is non-nil.
+	RefreshDone(err error)
+
+	// KeysUpdated records that count public keys were fetched at
+	// the given time, after a successful refresh.
+	KeysUpdated(count int, fetched time.Time)
+}
+
+// verifyDone records the final result of a call to one of the Verify
+// methods that began at start. Errors other than a *VerifyError, such
+// as a failed revocation lookup, are not a verdict on the token and
+// are not recorded.
+func (v *Verifier) verifyDone(start time.Time, err error) {
+	if v.metrics == nil {
+		return
+	}
+	var verr *VerifyError
+	if err != nil && !errors.As(err, &verr) {
+		return
+	}
+	v.metrics.VerifyDone(reasonOf(err), time.Since(start))
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeMetrics struct {
+	mu        sync.Mutex
+	verifies  map[Reason]int
+	refreshes int
+	keys      int
+}
+
+func (m *fakeMetrics) VerifyDone(reason Reason, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verifies[reason]++
+}
+
+func (m *fakeMetrics) RefreshDone(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refreshes++
+}
+
+func (m *fakeMetrics) KeysUpdated(count int, fetched time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys = count
+}
+
+func TestMetrics(t *testing.T) {
+	const projectID = "projectID"
+	now := time.Now()
+	valid := genToken(map[string]interface{}{
+		"exp":       now.Add(time.Minute).Unix(),
+		"iat":       now.Add(-time.Minute).Unix(),
+		"auth_time": now.Add(-time.Hour).Unix(),
+		"aud":       projectID,
+		"iss":       "https://securetoken.google.com/" + projectID,
+		"sub":       "sub",
+		"user_id":   "sub",
+	}, validKeys[0])
+	expired := genToken(map[string]interface{}{}, validKeys[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	m := &fakeMetrics{verifies: make(map[Reason]int)}
+	v := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys), WithMetrics(m))
+	v.Verify(ctx, valid)
+	v.Verify(ctx, valid)
+	v.Verify(ctx, expired)
+
+	// Checks made after verifying the token record only their
+	// final result.
+	v.ForTenant("tenant").Verify(ctx, valid)
+	v.revMu.Lock()
+	v.validSince = map[validSinceKey]validSinceEntry{{uid: "sub"}: {validSince: now, fetched: now}}
+	v.revMu.Unlock()
+	v.VerifyAndCheckRevoked(ctx, valid)
+	v.Close()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	want := map[Reason]int{0: 2, ReasonExpired: 1, ReasonWrongTenant: 1, ReasonRevoked: 1}
+	if !reflect.DeepEqual(m.verifies, want) {
+		t.Errorf("got verifies %v, want %v", m.verifies, want)
+	}
+	if m.refreshes != 1 || m.keys != len(validKeys) {
+		t.Errorf("got %d refreshes and %d keys, want 1 and %d", m.refreshes, m.keys, len(validKeys))
+	}
+}
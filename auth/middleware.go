@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// TokenVerifier verifies a token and returns the user it belongs to.
+// It is implemented by *Verifier, *TenantVerifier and *SessionCookies.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token []byte) (*User, error)
+}
+
+// ErrNoToken is passed to the middleware's error handler when a
+// request that requires authentication carries no token.
+var ErrNoToken = errors.New("auth: no token in request")
+
+type userKey struct{}
+
+// NewContext returns a copy of ctx that carries u.
+func NewContext(ctx context.Context, u *User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFromContext returns the user stored in ctx by the
+// middleware or interceptors, if any.
+func UserFromContext(ctx context.Context) (*User, bool) {
+	u, ok := ctx.Value(userKey{}).(*User)
+	return u, ok && u != nil
+}
+
+// A TokenSource extracts a token from a request.
+// It returns the empty string if the request carries no token.
+type TokenSource func(r *http.Request) string
+
+// A MiddlewareOption configures the middleware returned by Middleware.
+type MiddlewareOption func(*middleware)
+
+// TokenFromHeader reads the token from the named request header,
+// which must use the Bearer scheme. Headers with another scheme,
+// such as Basic, are treated as carrying no token.
+func TokenFromHeader(name string) MiddlewareOption {
+	return WithTokenSource(func(r *http.Request) string {
+		return BearerToken(r.Header.Get(name))
+	})
+}
+
+// TokenFromCookie reads the token from the named cookie. To accept
+// session cookies, pass a *SessionCookies to Middleware.
+func TokenFromCookie(name string) MiddlewareOption {
+	return WithTokenSource(func(r *http.Request) string {
+		c, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return c.Value
+	})
+}
+
+// TokenFromQuery reads the token from the named query parameter.
+func TokenFromQuery(param string) MiddlewareOption {
+	return WithTokenSource(func(r *http.Request) string {
+		return r.URL.Query().Get(param)
+	})
+}
+
+// WithTokenSource adds a custom token source.
+//
+// Sources are tried in the order they were given, and the first
+// token found is used. If no sources are given, the token is read
+// from the Authorization header.
+func WithTokenSource(src TokenSource) MiddlewareOption {
+	return func(m *middleware) {
+		m.sources = append(m.sources, src)
+	}
+}
+
+// AuthOptional lets requests without a token through unauthenticated.
+// Requests with an invalid token are still rejected.
+func AuthOptional() MiddlewareOption {
+	return func(m *middleware) {
+		m.optional = true
+	}
+}
+
+// WithErrorHandler sets the function that responds to requests that
+// fail authentication. The error is ErrNoToken or the error returned
+// by the verifier. The default handler responds with 401 Unauthorized,
+// 403 Forbidden for revoked tokens and tokens from other tenants,
+// and 503 Service Unavailable if no certificates are available.
+func WithErrorHandler(h func(w http.ResponseWriter, r *http.Request, err error)) MiddlewareOption {
+	return func(m *middleware) {
+		m.onError = h
+	}
+}
+
+type middleware struct {
+	v        TokenVerifier
+	sources  []TokenSource
+	optional bool
+	onError  func(w http.ResponseWriter, r *http.Request, err error)
+	next     http.Handler
+}
+
+// Middleware returns a function that wraps an http.Handler so that it
+// only serves requests carrying a token accepted by v.
+// The authenticated user can be retrieved with UserFromContext.
+func Middleware(v TokenVerifier, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	if v == nil {
+		panic("auth: nil verifier")
+	}
+	m := middleware{v: v, onError: defaultErrorHandler}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	if len(m.sources) == 0 {
+		TokenFromHeader("Authorization")(&m)
+	}
+	return func(next http.Handler) http.Handler {
+		m := m
+		m.next = next
+		return &m
+	}
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var token string
+	for _, src := range m.sources {
+		if token = src(r); token != "" {
+			break
+		}
+	}
+	if token == "" {
+		if m.optional {
+			m.next.ServeHTTP(w, r)
+		} else {
+			m.onError(w, r, ErrNoToken)
+		}
+		return
+	}
+
+	u, err := m.v.Verify(r.Context(), []byte(token))
+	if err != nil {
+		m.onError(w, r, err)
+		return
+	}
+	m.next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), u)))
+}
+
+func defaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	code := http.StatusUnauthorized
+	var verr *VerifyError
+	if errors.As(err, &verr) {
+		switch verr.Reason {
+		case ReasonRevoked, ReasonWrongTenant:
+			code = http.StatusForbidden
+		case ReasonNoCerts:
+			code = http.StatusServiceUnavailable
+		}
+	}
+	if code == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	http.Error(w, http.StatusText(code), code)
+}
+
+// BearerToken returns the token in an Authorization header or
+// metadata value that uses the Bearer scheme, or the empty string
+// if it uses another scheme.
+func BearerToken(header string) string {
+	const prefix = "bearer "
+	if len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	past := time.Now().Add(-1 * time.Minute).Unix()
+	future := time.Now().Add(1 * time.Minute).Unix()
+	const projectID = "projectID"
+
+	valid := string(genToken(map[string]interface{}{
+		"exp":     future,
+		"iat":     past,
+		"aud":     projectID,
+		"iss":     "https://securetoken.google.com/" + projectID,
+		"sub":     "sub",
+		"user_id": "sub",
+		"firebase": map[string]interface{}{
+			"tenant": "tenant-a",
+		},
+	}, validKeys[0]))
+	expired := string(genToken(map[string]interface{}{"exp": past}, validKeys[0]))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	verifier := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, ok := UserFromContext(r.Context()); ok {
+			w.Write([]byte(u.ID))
+		} else {
+			w.Write([]byte("anonymous"))
+		}
+	})
+
+	var tests = []struct {
+		Verifier TokenVerifier
+		Opts     []MiddlewareOption
+		Req      func(r *http.Request)
+		Code     int
+		Body     string
+	}{
+		0: {
+			Code: http.StatusUnauthorized,
+		},
+		1: {
+			Req:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+valid) },
+			Code: http.StatusOK,
+			Body: "sub",
+		},
+		2: {
+			Req:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+expired) },
+			Code: http.StatusUnauthorized,
+		},
+		3: {
+			Opts: []MiddlewareOption{AuthOptional()},
+			Code: http.StatusOK,
+			Body: "anonymous",
+		},
+		4: {
+			Opts: []MiddlewareOption{AuthOptional()},
+			Req:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+expired) },
+			Code: http.StatusUnauthorized,
+		},
+		5: {
+			Opts: []MiddlewareOption{TokenFromCookie("session"), TokenFromQuery("token")},
+			Req:  func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "session", Value: valid}) },
+			Code: http.StatusOK,
+			Body: "sub",
+		},
+		6: {
+			Opts: []MiddlewareOption{TokenFromCookie("session"), TokenFromQuery("token")},
+			Req:  func(r *http.Request) { r.URL.RawQuery = "token=" + valid },
+			Code: http.StatusOK,
+			Body: "sub",
+		},
+		7: {
+			Opts: []MiddlewareOption{TokenFromCookie("session")},
+			Req:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+valid) },
+			Code: http.StatusUnauthorized,
+		},
+		8: {
+			Verifier: verifier.ForTenant("tenant-b"),
+			Req:      func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+valid) },
+			Code:     http.StatusForbidden,
+		},
+		9: {
+			Opts: []MiddlewareOption{AuthOptional()},
+			Req:  func(r *http.Request) { r.Header.Set("Authorization", "Basic dXNlcjpwYXNz") },
+			Code: http.StatusOK,
+			Body: "anonymous",
+		},
+		10: {
+			Req:  func(r *http.Request) { r.Header.Set("Authorization", valid) },
+			Code: http.StatusUnauthorized,
+		},
+		11: {
+			Opts: []MiddlewareOption{WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+				w.WriteHeader(http.StatusTeapot)
+				w.Write([]byte(err.Error()))
+			})},
+			Code: http.StatusTeapot,
+			Body: ErrNoToken.Error(),
+		},
+	}
+
+	for i, test := range tests {
+		v := test.Verifier
+		if v == nil {
+			v = verifier
+		}
+		req := httptest.NewRequest("GET", "/", nil)
+		if test.Req != nil {
+			test.Req(req)
+		}
+		rec := httptest.NewRecorder()
+		Middleware(v, test.Opts...)(handler).ServeHTTP(rec, req)
+
+		if rec.Code != test.Code {
+			t.Errorf("%d: got status %d, want %d", i, rec.Code, test.Code)
+		}
+		if test.Body != "" && rec.Body.String() != test.Body {
+			t.Errorf("%d: got body %q, want %q", i, rec.Body.String(), test.Body)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"log/slog"
+	"time"
+)
+
+// A VerifierOption configures a Verifier. Options are passed to NewVerifier.
+type VerifierOption func(*Verifier)
+
+// WithClockSkew allows tokens to be accepted up to d after they expire
+// and up to d before they were issued, to tolerate clock differences
+// between the token issuer and this server. The default is zero.
+func WithClockSkew(d time.Duration) VerifierOption {
+	if d < 0 {
+		panic("auth: negative clock skew")
+	}
+	return func(v *Verifier) {
+		v.skew = d
+	}
+}
+
+// WithClock sets the function used to get the current time when
+// checking token timestamps. The default is time.Now.
+func WithClock(now func() time.Time) VerifierOption {
+	if now == nil {
+		panic("auth: nil clock")
+	}
+	return func(v *Verifier) {
+		v.now = now
+	}
+}
+
+// WithEmulator explicitly enables or disables Firebase Auth Emulator mode.
+//
+// In emulator mode the Verifier accepts only the unsigned tokens
+// (alg "none") issued by the emulator, never fetches certificates,
+// and still checks the aud, iss, sub, exp and iat claims.
+//
+// If this option is not given, emulator mode is enabled when the
+// FIREBASE_AUTH_EMULATOR_HOST environment variable is set, as with the
+// official SDKs, but only for demo projects (whose IDs start with
+// "demo-"); for other projects the variable is ignored and an error
+// is logged, so that a stray environment variable can never disable
+// verification in production. Production servers may also pass
+// WithEmulator(false) to ignore the variable entirely.
+// A warning is logged whenever a Verifier is created in emulator mode.
+func WithEmulator(enabled bool) VerifierOption {
+	return func(v *Verifier) {
+		v.emulator = enabled
+		v.emulatorSet = true
+	}
+}
+
+// WithTenants restricts the Verifier to tokens issued to users of the
+// given Identity Platform tenants. Include the empty string to also
+// accept users that do not belong to any tenant.
+// Tokens from other tenants fail with ReasonWrongTenant.
+func WithTenants(tenantIDs ...string) VerifierOption {
+	return func(v *Verifier) {
+		v.tenants = make(map[string]bool, len(tenantIDs))
+		for _, id := range tenantIDs {
+			v.tenants[id] = true
+		}
+	}
+}
+
+// WithKeySource sets where the Verifier gets the public keys that
+// tokens are signed with. The default is URLKeySource, reading from
+// Google's public key endpoint with the client passed to NewVerifier.
+func WithKeySource(ks KeySource) VerifierOption {
+	if ks == nil {
+		panic("auth: nil key source")
+	}
+	return func(v *Verifier) {
+		v.keys = ks
+	}
+}
+
+// WithCacheDir makes the Verifier persist the latest public keys in the
+// given directory, and load them from there when it is created if they
+// have not yet expired. This lets tokens be verified immediately after
+// a restart, even if the key source is unreachable.
+//
+// The directory must exist and should only be writable by this program.
+func WithCacheDir(dir string) VerifierOption {
+	return func(v *Verifier) {
+		v.cacheDir = dir
+	}
+}
+
+// WithRefreshHook registers a function that is called after every
+// attempt to fetch the public keys, for example to feed alerting.
+// It is called from the Verifier's background goroutine and should
+// not block.
+func WithRefreshHook(hook func(RefreshEvent)) VerifierOption {
+	return func(v *Verifier) {
+		v.onRefresh = hook
+	}
+}
+
+// WithLogger sets the logger that receives the Verifier's events, such as
+// failures to fetch public keys. The default is slog.Default(), as in the
+// fcm package. A key source that implements LoggingKeySource logs to the
+// same logger.
+func WithLogger(logger *slog.Logger) VerifierOption {
+	if logger == nil {
+		panic("auth: nil logger")
+	}
+	return func(v *Verifier) {
+		v.logger = logger
+	}
+}
+
+// WithBackoff sets how long the Verifier waits between failed attempts
+// to fetch the public keys. The default is DefaultBackoff.
+func WithBackoff(b Backoff) VerifierOption {
+	if b.Base <= 0 || b.Max < b.Base || b.Jitter < 0 || b.Jitter > 1 {
+		panic("auth: invalid backoff")
+	}
+	return func(v *Verifier) {
+		v.backoff = b
+	}
+}
+
+// WithRefreshMargin makes the Verifier refresh the public keys d before
+// they expire, so that failures can be retried before the keys go stale.
+// The default is one minute.
+func WithRefreshMargin(d time.Duration) VerifierOption {
+	if d < 0 {
+		panic("auth: negative refresh margin")
+	}
+	return func(v *Verifier) {
+		v.refreshMargin = d
+	}
+}
+
+// WithMinRefreshInterval sets how often, at most, a token signed with
+// an unknown key can make the Verifier fetch the public keys again.
+// This keeps key rotations from causing failures without letting
+// clients force a fetch for every request. The default is 30 seconds.
+func WithMinRefreshInterval(d time.Duration) VerifierOption {
+	if d < 0 {
+		panic("auth: negative refresh interval")
+	}
+	return func(v *Verifier) {
+		v.minRefreshInterval = d
+	}
+}
+
+// WithMetrics sets a Metrics to record the Verifier's activity.
+func WithMetrics(m Metrics) VerifierOption {
+	if m == nil {
+		panic("auth: nil metrics")
+	}
+	return func(v *Verifier) {
+		v.metrics = m
+	}
+}
+
+// WithTokenCache caches up to size successfully verified tokens, so
+// that verifying the same token again skips the signature check.
+// A cached token is returned until its expiration time minus the clock
+// skew, or until the public keys change. Tokens are cached by their
+// SHA-256 hash; the least recently used token is evicted when the
+// cache is full. See Verifier.TokenCacheStats.
+//
+// The User returned for a cached token shares its Claims with other
+// calls, so Claims must not be modified.
+func WithTokenCache(size int) VerifierOption {
+	if size <= 0 {
+		panic("auth: non-positive token cache size")
+	}
+	return func(v *Verifier) {
+		v.tokens = newTokenCache(size)
+	}
+}
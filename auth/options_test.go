@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClockSkew(t *testing.T) {
+	const projectID = "projectID"
+	now := time.Unix(1500000000, 0)
+	clock := WithClock(func() time.Time { return now })
+
+	token := func(iat, exp time.Time) []byte {
+		return genToken(map[string]interface{}{
+			"exp":     exp.Unix(),
+			"iat":     iat.Unix(),
+			"aud":     projectID,
+			"iss":     "https://securetoken.google.com/" + projectID,
+			"sub":     "sub",
+			"user_id": "sub",
+		}, validKeys[0])
+	}
+
+	var tests = []struct {
+		Skew  time.Duration
+		Token []byte
+		Err   error
+	}{
+		0: {Token: token(now.Add(-time.Minute), now.Add(time.Minute))},
+		1: {Token: token(now.Add(2*time.Second), now.Add(time.Hour)), Err: ErrNotYetValid},
+		2: {Token: token(now.Add(2*time.Second), now.Add(time.Hour)), Skew: 5 * time.Second},
+		3: {Token: token(now.Add(10*time.Second), now.Add(time.Hour)), Skew: 5 * time.Second, Err: ErrNotYetValid},
+		4: {Token: token(now.Add(-time.Hour), now.Add(-2*time.Second)), Err: ErrExpired},
+		5: {Token: token(now.Add(-time.Hour), now.Add(-2*time.Second)), Skew: 5 * time.Second},
+		6: {Token: token(now.Add(-time.Hour), now.Add(-10*time.Second)), Skew: 5 * time.Second, Err: ErrExpired},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for i, test := range tests {
+		verifier := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys), clock, WithClockSkew(test.Skew))
+		_, err := verifier.Verify(ctx, test.Token)
+		if test.Err == nil && err != nil {
+			t.Errorf("%d: got err %v, want nil", i, err)
+		} else if test.Err != nil && !errors.Is(err, test.Err) {
+			t.Errorf("%d: got err %v, want %v", i, err, test.Err)
+		}
+	}
+}
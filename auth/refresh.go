@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// requestRefresh asks fetchCertLoop to refresh the certs early.
+// It does not block.
+func (v *Verifier) requestRefresh() {
+	select {
+	case v.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// refreshKeys makes fetchCertLoop fetch the certs now because kid
+// was not found, and waits for the fetch to finish. It reports whether
+// the certs may have changed; it does nothing if the last fetch was
+// less than minRefreshInterval ago.
+//
+// Concurrent callers share a single fetch.
+func (v *Verifier) refreshKeys(ctx context.Context, kid string) bool {
+	v.mu.RLock()
+	_, found := v.certs[kid]
+	if found {
+		v.mu.RUnlock()
+		return true // another caller's refresh already fetched it
+	}
+	if time.Since(v.fetchedAt) < v.minRefreshInterval {
+		v.mu.RUnlock()
+		return false
+	}
+	// Request the refresh while holding the lock, so that
+	// fetchFinished cannot discard the request without
+	// closing the fetchDone we wait on.
+	fetchDone := v.fetchDone
+	v.requestRefresh()
+	v.mu.RUnlock()
+
+	select {
+	case <-fetchDone:
+		return true
+	case <-v.done:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// fetchFinished records that fetchCertLoop attempted a fetch at the
+// given time, and wakes up refreshKeys. v.mu must be held.
+func (v *Verifier) fetchFinished(at time.Time) {
+	v.fetchedAt = at
+	close(v.fetchDone)
+	v.fetchDone = make(chan struct{})
+
+	// Any pending refresh request was made before this fetch
+	// finished, so it has been served.
+	select {
+	case <-v.refresh:
+	default:
+	}
+}
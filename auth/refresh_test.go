@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rotatingKeySource serves validKeys[0] until rotate is called,
+// and validKeys[1] afterwards.
+type rotatingKeySource struct {
+	mu      sync.Mutex
+	rotated bool
+	calls   int
+}
+
+func (s *rotatingKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	k := validKeys[0]
+	if s.rotated {
+		k = validKeys[1]
+	}
+	return map[string]*rsa.PublicKey{k.id: &k.pk.PublicKey}, time.Hour, nil
+}
+
+func (s *rotatingKeySource) rotate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotated = true
+}
+
+func (s *rotatingKeySource) numCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func TestRefreshOnUnknownKid(t *testing.T) {
+	const projectID = "projectID"
+	token := genToken(map[string]interface{}{
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		"iat":     time.Now().Add(-time.Minute).Unix(),
+		"aud":     projectID,
+		"iss":     "https://securetoken.google.com/" + projectID,
+		"sub":     "sub",
+		"user_id": "sub",
+	}, validKeys[1])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	src := &rotatingKeySource{}
+	v := NewVerifier(ctx, projectID, nil, WithKeySource(src), WithMinRefreshInterval(0))
+	defer v.Close()
+	if err := v.Ready(ctx); err != nil {
+		t.Fatalf("Ready: %v", err)
+	}
+
+	// Many requests with the new key arrive at once
+	// and should share a single refresh.
+	src.rotate()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := v.Verify(ctx, token); err != nil {
+				t.Errorf("got err %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := src.numCalls(); n != 2 {
+		t.Errorf("got %d fetches, want 2", n)
+	}
+}
+
+func TestRefreshOnUnknownKidRateLimited(t *testing.T) {
+	const projectID = "projectID"
+	token := genToken(map[string]interface{}{
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		"iat":     time.Now().Add(-time.Minute).Unix(),
+		"aud":     projectID,
+		"iss":     "https://securetoken.google.com/" + projectID,
+		"sub":     "sub",
+		"user_id": "sub",
+	}, invalidKey)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	src := &rotatingKeySource{}
+	v := NewVerifier(ctx, projectID, nil, WithKeySource(src), WithMinRefreshInterval(time.Hour))
+	defer v.Close()
+	if err := v.Ready(ctx); err != nil {
+		t.Fatalf("Ready: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := v.Verify(ctx, token); !errors.Is(err, ErrUnknownKeyID) {
+			t.Errorf("got err %v, want ErrUnknownKeyID", err)
+		}
+	}
+	if n := src.numCalls(); n != 1 {
+		t.Errorf("got %d fetches, want 1", n)
+	}
+}
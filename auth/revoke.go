@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// validSinceTTL is how long a user's revocation time is cached.
+const validSinceTTL = 30 * time.Second
+
+// maxValidSinceEntries bounds the revocation cache; once it reaches
+// this size, expired entries are evicted, or the oldest entry if
+// none have expired.
+const maxValidSinceEntries = 10000
+
+// validSinceKey identifies a user in the revocation cache. The same
+// user ID may belong to different users in different tenants.
+type validSinceKey struct {
+	tenantID string
+	uid      string
+}
+
+type validSinceEntry struct {
+	validSince time.Time
+	fetched    time.Time
+}
+
+// VerifyAndCheckRevoked is like Verify, but additionally checks that
+// the user's tokens have not been revoked since the token was issued.
+// If they have, it returns ErrTokenRevoked.
+//
+// Checking revocation requires a lookup against the Identity Toolkit API,
+// so the Verifier must have been created with an authorized client,
+// such as one created with golang.org/x/oauth2/google.
+// Lookups are cached for a short while.
+func (v *Verifier) VerifyAndCheckRevoked(ctx context.Context, token []byte) (*User, error) {
+	start := time.Now()
+	u, err := v.checkRevoked(ctx, token)
+	v.verifyDone(start, err)
+	return u, err
+}
+
+func (v *Verifier) checkRevoked(ctx context.Context, token []byte) (*User, error) {
+	u, err := v.verifyCached(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	authTime, ok := u.AuthTime()
+	if !ok {
+		return nil, verifyErrorf(ReasonMalformed, "auth: token has no auth_time")
+	}
+	validSince, err := v.tokensValidSince(ctx, u.TenantID, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if authTime.Before(validSince) {
+		return nil, ErrTokenRevoked
+	}
+	return u, nil
+}
+
+// tokensValidSince returns the time before which all of the user's
+// tokens are considered revoked. tenantID is empty for users
+// that do not belong to a tenant.
+func (v *Verifier) tokensValidSince(ctx context.Context, tenantID, uid string) (time.Time, error) {
+	now := v.now()
+	key := validSinceKey{tenantID: tenantID, uid: uid}
+	v.revMu.Lock()
+	entry, ok := v.validSince[key]
+	v.revMu.Unlock()
+	if ok && now.Sub(entry.fetched) < validSinceTTL {
+		return entry.validSince, nil
+	}
+
+	req := struct {
+		LocalID  []string `json:"localId"`
+		TenantID string   `json:"tenantId,omitempty"`
+	}{[]string{uid}, tenantID}
+	var resp struct {
+		Users []struct {
+			LocalID    string `json:"localId"`
+			ValidSince string `json:"validSince"`
+		} `json:"users"`
+	}
+	if err := v.toolkit.post(ctx, "/accounts:lookup", req, &resp); err != nil {
+		return time.Time{}, err
+	}
+	if len(resp.Users) == 0 {
+		return time.Time{}, fmt.Errorf("%w: uid %q", ErrUserNotFound, uid)
+	}
+
+	var validSince time.Time
+	if s := resp.Users[0].ValidSince; s != "" {
+		seconds, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("auth: could not parse validSince %q: %v", s, err)
+		}
+		validSince = time.Unix(seconds, 0)
+	}
+
+	v.revMu.Lock()
+	if v.validSince == nil {
+		v.validSince = make(map[validSinceKey]validSinceEntry)
+	}
+	if len(v.validSince) >= maxValidSinceEntries {
+		// Evict the expired entries, or failing that the oldest one.
+		var oldestKey validSinceKey
+		var oldest time.Time
+		for k, e := range v.validSince {
+			if now.Sub(e.fetched) >= validSinceTTL {
+				delete(v.validSince, k)
+			} else if oldest.IsZero() || e.fetched.Before(oldest) {
+				oldestKey, oldest = k, e.fetched
+			}
+		}
+		if len(v.validSince) >= maxValidSinceEntries {
+			delete(v.validSince, oldestKey)
+		}
+	}
+	v.validSince[key] = validSinceEntry{validSince: validSince, fetched: now}
+	v.revMu.Unlock()
+	return validSince, nil
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVerifyAndCheckRevoked(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	const projectID = "projectID"
+
+	var mu sync.Mutex
+	lookups := 0
+	// Users are keyed by tenant ID, then user ID.
+	validSince := map[string]map[string]int64{
+		"": {
+			"revoked":      now.Add(-10 * time.Minute).Unix(),
+			"not-revoked":  now.Add(-2 * time.Hour).Unix(),
+			"also-revoked": now.Add(-10 * time.Minute).Unix(),
+			"shared":       now.Add(-2 * time.Hour).Unix(),
+		},
+		"tenant-a": {
+			"shared": now.Add(-10 * time.Minute).Unix(),
+		},
+	}
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/projects/"+projectID+"/accounts:lookup" {
+			http.NotFound(w, req)
+			return
+		}
+		var body struct {
+			LocalID  []string `json:"localId"`
+			TenantID string   `json:"tenantId"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		mu.Lock()
+		lookups++
+		mu.Unlock()
+
+		var users []map[string]string
+		for _, uid := range body.LocalID {
+			if vs, ok := validSince[body.TenantID][uid]; ok {
+				users = append(users, map[string]string{
+					"localId":    uid,
+					"validSince": strconv.FormatInt(vs, 10),
+				})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"users": users})
+	}))
+	defer serv.Close()
+
+	tenantToken := func(tenantID, uid string) []byte {
+		payload := map[string]interface{}{
+			"exp":       now.Add(time.Minute).Unix(),
+			"iat":       now.Add(-time.Minute).Unix(),
+			"auth_time": now.Add(-1 * time.Hour).Unix(),
+			"aud":       projectID,
+			"iss":       "https://securetoken.google.com/" + projectID,
+			"sub":       uid,
+			"user_id":   uid,
+		}
+		if tenantID != "" {
+			payload["firebase"] = map[string]interface{}{"tenant": tenantID}
+		}
+		return genToken(payload, validKeys[0])
+	}
+	token := func(uid string) []byte { return tenantToken("", uid) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	verifier := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys))
+	verifier.toolkit.baseURL = serv.URL
+
+	if _, err := verifier.VerifyAndCheckRevoked(ctx, token("revoked")); err != ErrTokenRevoked {
+		t.Errorf("revoked: got err %v, want ErrTokenRevoked", err)
+	}
+	for i := 0; i < 2; i++ {
+		if u, err := verifier.VerifyAndCheckRevoked(ctx, token("not-revoked")); err != nil {
+			t.Errorf("not-revoked: got err %v", err)
+		} else if u.ID != "not-revoked" {
+			t.Errorf("not-revoked: got user ID %q", u.ID)
+		}
+	}
+	if _, err := verifier.VerifyAndCheckRevoked(ctx, token("unknown")); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown: got err %v, want ErrUserNotFound", err)
+	}
+	noAuthTime := genToken(map[string]interface{}{
+		"exp":     now.Add(time.Minute).Unix(),
+		"iat":     now.Add(-time.Minute).Unix(),
+		"aud":     projectID,
+		"iss":     "https://securetoken.google.com/" + projectID,
+		"sub":     "not-revoked",
+		"user_id": "not-revoked",
+	}, validKeys[0])
+	if _, err := verifier.VerifyAndCheckRevoked(ctx, noAuthTime); !errors.Is(err, ErrMalformed) {
+		t.Errorf("no auth_time: got err %v, want ErrMalformed", err)
+	}
+
+	// The same user ID in a tenant is a different user, looked up
+	// and cached separately.
+	if _, err := verifier.VerifyAndCheckRevoked(ctx, token("shared")); err != nil {
+		t.Errorf("shared: got err %v", err)
+	}
+	if _, err := verifier.VerifyAndCheckRevoked(ctx, tenantToken("tenant-a", "shared")); err != ErrTokenRevoked {
+		t.Errorf("tenant-a/shared: got err %v, want ErrTokenRevoked", err)
+	}
+	if _, err := verifier.VerifyAndCheckRevoked(ctx, tenantToken("tenant-b", "shared")); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("tenant-b/shared: got err %v, want ErrUserNotFound", err)
+	}
+
+	// The second lookup of "not-revoked" should have been cached.
+	mu.Lock()
+	if lookups != 6 {
+		t.Errorf("got %d lookups, want 6", lookups)
+	}
+	mu.Unlock()
+
+	// The cache stays bounded when it is full of fresh entries.
+	verifier.revMu.Lock()
+	for i := 0; len(verifier.validSince) < maxValidSinceEntries; i++ {
+		verifier.validSince[validSinceKey{uid: "filler" + strconv.Itoa(i)}] = validSinceEntry{fetched: now.Add(time.Duration(i) * time.Millisecond)}
+	}
+	verifier.revMu.Unlock()
+	if _, err := verifier.VerifyAndCheckRevoked(ctx, token("also-revoked")); err != ErrTokenRevoked {
+		t.Errorf("revoked with full cache: got err %v, want ErrTokenRevoked", err)
+	}
+	verifier.revMu.Lock()
+	n := len(verifier.validSince)
+	_, present := verifier.validSince[validSinceKey{uid: "filler0"}]
+	verifier.revMu.Unlock()
+	if n > maxValidSinceEntries || present {
+		t.Errorf("full cache: got %d entries, oldest present: %v", n, present)
+	}
+}
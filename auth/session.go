@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// sessionCertificateURL is where Google publishes the public keys
+// used to sign session cookies. They differ from the ID token keys.
+const sessionCertificateURL = "https://www.googleapis.com/identitytoolkit/v3/relyingparty/publicKeys"
+
+const (
+	// MinSessionCookieDuration is the shortest lifetime a session cookie may have.
+	MinSessionCookieDuration = 5 * time.Minute
+
+	// MaxSessionCookieDuration is the longest lifetime a session cookie may have.
+	MaxSessionCookieDuration = 14 * 24 * time.Hour
+)
+
+// SessionCookies creates and verifies Firebase session cookies.
+//
+// For more information, see the documentation at:
+// https://firebase.google.com/docs/auth/admin/manage-cookies
+type SessionCookies struct {
+	idTokens *Verifier
+	cookies  *Verifier
+	toolkit  *toolkit
+}
+
+// NewSessionCookies returns a SessionCookies for the project that
+// idTokens verifies ID tokens for.
+//
+// Creating session cookies requires an authorized client, such as one
+// created with golang.org/x/oauth2/google using service account
+// credentials. If client is nil, http.DefaultClient is used, which is
+// only sufficient for verifying session cookies.
+//
+// The session cookie public keys are fetched in the background
+// until ctx is cancelled or Close is called. The options apply to session cookie
+// verification only.
+func NewSessionCookies(ctx context.Context, idTokens *Verifier, client *http.Client, opts ...VerifierOption) *SessionCookies {
+	if idTokens == nil {
+		panic("auth: nil ID token verifier")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	projectID := idTokens.projectID
+	return &SessionCookies{
+		idTokens: idTokens,
+		cookies:  newVerifier(ctx, projectID, "https://session.firebase.google.com/"+projectID, sessionCertificateURL, client, opts),
+		toolkit:  newToolkit(projectID, client),
+	}
+}
+
+// CreateSessionCookie verifies idToken and exchanges it for a session
+// cookie that expires after expiresIn, which must be between
+// MinSessionCookieDuration and MaxSessionCookieDuration.
+func (s *SessionCookies) CreateSessionCookie(ctx context.Context, idToken []byte, expiresIn time.Duration) (string, error) {
+	if expiresIn < MinSessionCookieDuration || expiresIn > MaxSessionCookieDuration {
+		return "", fmt.Errorf("auth: session cookie duration must be between %v and %v, got %v",
+			MinSessionCookieDuration, MaxSessionCookieDuration, expiresIn)
+	}
+	if _, err := s.idTokens.Verify(ctx, idToken); err != nil {
+		return "", err
+	}
+
+	req := struct {
+		IDToken       string `json:"idToken"`
+		ValidDuration int64  `json:"validDuration,string"`
+	}{
+		IDToken:       string(idToken),
+		ValidDuration: int64(expiresIn / time.Second),
+	}
+	var resp struct {
+		SessionCookie string `json:"sessionCookie"`
+	}
+	if err := s.toolkit.post(ctx, ":createSessionCookie", req, &resp); err != nil {
+		return "", err
+	}
+	if resp.SessionCookie == "" {
+		return "", errors.New("auth: server returned an empty session cookie")
+	}
+	return resp.SessionCookie, nil
+}
+
+// VerifySessionCookie verifies a session cookie created by
+// CreateSessionCookie and returns the user it belongs to.
+func (s *SessionCookies) VerifySessionCookie(ctx context.Context, cookie []byte) (*User, error) {
+	return s.cookies.Verify(ctx, cookie)
+}
+
+// Verify is the same as VerifySessionCookie. It lets s be used as a
+// TokenVerifier, for example with Middleware and TokenFromCookie.
+func (s *SessionCookies) Verify(ctx context.Context, cookie []byte) (*User, error) {
+	return s.VerifySessionCookie(ctx, cookie)
+}
+
+// Ready blocks until s has public keys to verify session cookies
+// with, or until ctx is done or s is closed. See Verifier.Ready.
+func (s *SessionCookies) Ready(ctx context.Context) error {
+	return s.cookies.Ready(ctx)
+}
+
+// Status returns the current state of the session cookie public keys.
+func (s *SessionCookies) Status() VerifierStatus {
+	return s.cookies.Status()
+}
+
+// Close stops fetching session cookie public keys. It does not close
+// the ID token Verifier passed to NewSessionCookies.
+func (s *SessionCookies) Close() error {
+	return s.cookies.Close()
+}
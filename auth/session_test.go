@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionCookies(t *testing.T) {
+	t.Parallel()
+	past := time.Now().Add(-1 * time.Minute).Unix()
+	future := time.Now().Add(1 * time.Minute).Unix()
+	const projectID = "projectID"
+
+	claims := func(issuer string) map[string]interface{} {
+		return map[string]interface{}{
+			"exp":     future,
+			"iat":     past,
+			"aud":     projectID,
+			"iss":     issuer,
+			"sub":     "sub",
+			"user_id": "sub",
+		}
+	}
+	idToken := genToken(claims("https://securetoken.google.com/"+projectID), validKeys[0])
+	cookie := genToken(claims("https://session.firebase.google.com/"+projectID), validKeys[1])
+
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/projects/"+projectID+":createSessionCookie" {
+			http.NotFound(w, req)
+			return
+		}
+		var body struct {
+			IDToken       string `json:"idToken"`
+			ValidDuration string `json:"validDuration"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("could not decode request: %v", err)
+		}
+		if body.IDToken != string(idToken) || body.ValidDuration != "3600" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"code": 400, "message": "INVALID_ID_TOKEN"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"sessionCookie": string(cookie)})
+	}))
+	defer serv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	sc := NewSessionCookies(ctx, NewVerifier(ctx, projectID, nil, WithKeySource(testKeys)), nil, WithKeySource(testKeys))
+	sc.toolkit.baseURL = serv.URL
+	defer sc.Close()
+
+	if err := sc.Ready(ctx); err != nil {
+		t.Fatalf("Ready: %v", err)
+	}
+	if st := sc.Status(); !st.Ready || len(st.KeyIDs) != len(validKeys) {
+		t.Errorf("Status: got %+v, want ready with %d keys", st, len(validKeys))
+	}
+
+	if _, err := sc.CreateSessionCookie(ctx, idToken, time.Minute); err == nil || !strings.Contains(err.Error(), "duration") {
+		t.Errorf("CreateSessionCookie with short duration: got err %v", err)
+	}
+	if _, err := sc.CreateSessionCookie(ctx, cookie, time.Hour); err == nil || !strings.Contains(err.Error(), "unexpected issuer") {
+		t.Errorf("CreateSessionCookie with cookie as ID token: got err %v", err)
+	}
+
+	got, err := sc.CreateSessionCookie(ctx, idToken, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateSessionCookie: %v", err)
+	} else if got != string(cookie) {
+		t.Fatalf("CreateSessionCookie: got %q, want %q", got, cookie)
+	}
+
+	user, err := sc.VerifySessionCookie(ctx, []byte(got))
+	if err != nil {
+		t.Fatalf("VerifySessionCookie: %v", err)
+	} else if user.ID != "sub" {
+		t.Errorf("VerifySessionCookie: got user ID %q, want %q", user.ID, "sub")
+	}
+
+	if _, err := sc.VerifySessionCookie(ctx, idToken); err == nil || !strings.Contains(err.Error(), "unexpected issuer") {
+		t.Errorf("VerifySessionCookie with ID token: got err %v", err)
+	}
+
+	// SessionCookies can be used with the middleware.
+	handler := Middleware(sc, TokenFromCookie("session"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, _ := UserFromContext(r.Context())
+		w.Write([]byte(u.ID))
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: got})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "sub" {
+		t.Errorf("Middleware with session cookie: got %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -29,7 +29,8 @@ type key struct {
 var validKeys []key
 var invalidKey key
 
-var testClient *http.Client
+// testKeys serves validKeys from a local server started by TestMain.
+var testKeys KeySource
 
 func genToken(payload map[string]interface{}, key key) []byte {
 	tok := jws.New(payload, crypto.SigningMethodRS256)
@@ -101,7 +102,7 @@ func generateKeys() {
 func TestMain(m *testing.M) {
 	generateKeys()
 	serv := httptest.NewServer(http.HandlerFunc(keyHandler))
-	certificateURL = serv.URL
+	testKeys = X509KeySource(serv.URL, nil)
 	code := m.Run()
 	serv.Close()
 	os.Exit(code)
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// TenantVerifier verifies tokens for a single Identity Platform tenant.
+// It shares its public keys with the Verifier it was created from.
+type TenantVerifier struct {
+	v        *Verifier
+	tenantID string
+}
+
+// ForTenant returns a TenantVerifier that only accepts tokens issued
+// to users of the given tenant.
+func (v *Verifier) ForTenant(tenantID string) *TenantVerifier {
+	if tenantID == "" {
+		panic("auth: empty tenant ID")
+	}
+	return &TenantVerifier{v: v, tenantID: tenantID}
+}
+
+// TenantID returns the ID of the tenant whose tokens tv accepts.
+func (tv *TenantVerifier) TenantID() string {
+	return tv.tenantID
+}
+
+// Verify is like Verifier.Verify, but additionally fails with
+// ReasonWrongTenant if the token belongs to another tenant,
+// or to no tenant at all.
+func (tv *TenantVerifier) Verify(ctx context.Context, token []byte) (*User, error) {
+	start := time.Now()
+	u, err := tv.verify(ctx, token)
+	tv.v.verifyDone(start, err)
+	return u, err
+}
+
+func (tv *TenantVerifier) verify(ctx context.Context, token []byte) (*User, error) {
+	u, err := tv.v.verifyCached(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if u.TenantID != tv.tenantID {
+		return nil, verifyErrorf(ReasonWrongTenant, "auth: unexpected tenant (%q), want %q", u.TenantID, tv.tenantID)
+	}
+	return u, nil
+}
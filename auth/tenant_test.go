@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTenants(t *testing.T) {
+	past := time.Now().Add(-1 * time.Minute).Unix()
+	future := time.Now().Add(1 * time.Minute).Unix()
+	const projectID = "projectID"
+
+	token := func(tenantID string) []byte {
+		payload := map[string]interface{}{
+			"exp":     future,
+			"iat":     past,
+			"aud":     projectID,
+			"iss":     "https://securetoken.google.com/" + projectID,
+			"sub":     "sub",
+			"user_id": "sub",
+		}
+		if tenantID != "" {
+			payload["firebase"] = map[string]interface{}{"tenant": tenantID}
+		}
+		return genToken(payload, validKeys[0])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	verifier := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys))
+	if u, err := verifier.Verify(ctx, token("tenant-a")); err != nil {
+		t.Errorf("Verify: got err %v", err)
+	} else if u.TenantID != "tenant-a" {
+		t.Errorf("Verify: got tenant %q, want %q", u.TenantID, "tenant-a")
+	}
+
+	tv := verifier.ForTenant("tenant-a")
+	if _, err := tv.Verify(ctx, token("tenant-a")); err != nil {
+		t.Errorf("ForTenant: got err %v", err)
+	}
+	for _, tenantID := range []string{"tenant-b", ""} {
+		if _, err := tv.Verify(ctx, token(tenantID)); !errors.Is(err, ErrWrongTenant) {
+			t.Errorf("ForTenant with tenant %q: got err %v, want ErrWrongTenant", tenantID, err)
+		}
+	}
+
+	allowed := NewVerifier(ctx, projectID, nil, WithKeySource(testKeys), WithTenants("tenant-a", "tenant-b"))
+	for tenantID, want := range map[string]error{"tenant-a": nil, "tenant-b": nil, "tenant-c": ErrWrongTenant, "": ErrWrongTenant} {
+		if _, err := allowed.Verify(ctx, token(tenantID)); !errors.Is(err, want) {
+			t.Errorf("WithTenants with tenant %q: got err %v, want %v", tenantID, err, want)
+		}
+	}
+}
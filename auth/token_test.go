@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SermoDigital/jose/crypto"
+	"github.com/SermoDigital/jose/jws"
+)
+
+func serviceAccountJSON(t *testing.T, k key) []byte {
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(k.pk),
+	})
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "projectID",
+		"private_key_id": k.id,
+		"private_key":    string(keyPEM),
+		"client_email":   "signer@projectID.iam.gserviceaccount.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestCreateCustomToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth-token-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "key.json")
+	if err := ioutil.WriteFile(filename, serviceAccountJSON(t, validKeys[0]), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewTokenSignerFromFile(filename)
+	if err != nil {
+		t.Fatalf("NewTokenSignerFromFile: %v", err)
+	}
+	now := time.Unix(1500000000, 0)
+	signer.now = func() time.Time { return now }
+
+	token, err := signer.CreateCustomToken("some-uid", map[string]interface{}{"premium": true})
+	if err != nil {
+		t.Fatalf("CreateCustomToken: %v", err)
+	}
+
+	tok, err := jws.ParseCompact([]byte(token))
+	if err != nil {
+		t.Fatalf("could not parse token: %v", err)
+	}
+	if err := tok.Verify(&validKeys[0].pk.PublicKey, crypto.SigningMethodRS256); err != nil {
+		t.Fatalf("could not verify token: %v", err)
+	}
+	if kid := tok.Protected().Get("kid"); kid != validKeys[0].id {
+		t.Errorf("got kid %v, want %v", kid, validKeys[0].id)
+	}
+
+	claims := jws.Claims(tok.Payload().(map[string]interface{}))
+	const email = "signer@projectID.iam.gserviceaccount.com"
+	want := map[string]interface{}{
+		"iss": email,
+		"sub": email,
+		"aud": customTokenAudience,
+		"uid": "some-uid",
+		"iat": float64(now.Unix()),
+		"exp": float64(now.Add(time.Hour).Unix()),
+	}
+	for k, v := range want {
+		if got := claims.Get(k); got != v {
+			t.Errorf("claim %s: got %v, want %v", k, got, v)
+		}
+	}
+	if dev, _ := claims.Get("claims").(map[string]interface{}); dev["premium"] != true {
+		t.Errorf("got developer claims %v, want premium=true", claims.Get("claims"))
+	}
+}
+
+func TestCreateCustomTokenErrors(t *testing.T) {
+	signer, err := NewTokenSigner(serviceAccountJSON(t, validKeys[1]))
+	if err != nil {
+		t.Fatalf("NewTokenSigner: %v", err)
+	}
+
+	var tests = []struct {
+		UID    string
+		Claims map[string]interface{}
+		Err    string
+	}{
+		0: {UID: "", Err: "empty user ID"},
+		1: {UID: strings.Repeat("a", maxUIDLength+1), Err: "longer than"},
+		2: {UID: "uid", Claims: map[string]interface{}{"sub": "other"}, Err: `"sub" is reserved`},
+		3: {UID: "uid", Claims: map[string]interface{}{"firebase": "x"}, Err: `"firebase" is reserved`},
+	}
+	for i, test := range tests {
+		_, err := signer.CreateCustomToken(test.UID, test.Claims)
+		if err == nil || !strings.Contains(err.Error(), test.Err) {
+			t.Errorf("%d: got err %v, want %q", i, err, test.Err)
+		}
+	}
+
+	if _, err := NewTokenSigner([]byte(`{"type": "authorized_user"}`)); err == nil {
+		t.Errorf("NewTokenSigner with user credentials: got err == nil")
+	}
+}
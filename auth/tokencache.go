@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// TokenCacheStats reports the activity of the token cache
+// enabled by WithTokenCache.
+type TokenCacheStats struct {
+	Hits   uint64 // calls to Verify answered from the cache
+	Misses uint64 // calls to Verify that verified the token
+	Size   int    // number of tokens currently cached
+}
+
+// TokenCacheStats returns statistics about the token cache.
+// It returns the zero value if WithTokenCache was not given.
+func (v *Verifier) TokenCacheStats() TokenCacheStats {
+	if v.tokens == nil {
+		return TokenCacheStats{}
+	}
+	return v.tokens.stats()
+}
+
+// verifyCached is like verify, but answers from the token cache
+// when it is enabled.
+func (v *Verifier) verifyCached(ctx context.Context, token []byte) (*User, error) {
+	if v.tokens == nil {
+		return v.verify(ctx, token)
+	}
+	key := sha256.Sum256(token)
+	if u, ok := v.tokens.get(key, v.now()); ok {
+		return u, nil
+	}
+	// Note the generation before verifying, so that a result
+	// obtained with certs that have since been replaced is
+	// not cached.
+	gen := v.tokens.generation()
+	u, err := v.verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if exp, ok := u.Claims.Expiration(); ok {
+		v.tokens.add(key, u, exp.Add(-v.skew), gen)
+	}
+	return u, nil
+}
+
+// A tokenCache is an LRU cache of verified tokens,
+// keyed by the SHA-256 hash of the raw token.
+type tokenCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List // of *tokenEntry, most recently used first
+	entries map[[sha256.Size]byte]*list.Element
+	gen     uint64 // incremented by purge
+	hits    uint64
+	misses  uint64
+}
+
+type tokenEntry struct {
+	key     [sha256.Size]byte
+	user    User
+	expires time.Time
+}
+
+func newTokenCache(size int) *tokenCache {
+	return &tokenCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// get returns a copy of the user cached for key,
+// if there is one and it has not expired at now.
+func (c *tokenCache) get(key [sha256.Size]byte, now time.Time) (*User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	e := el.Value.(*tokenEntry)
+	if !now.Before(e.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		c.misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	c.hits++
+	u := copyUser(&e.user)
+	return &u, true
+}
+
+// generation returns the current generation of the cache.
+func (c *tokenCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// add caches u for key until expires, evicting the least recently
+// used entry if the cache is full. It does nothing if the cache has
+// been purged since generation gen.
+func (c *tokenCache) add(key [sha256.Size]byte, u *User, expires time.Time, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*tokenEntry)
+		e.user, e.expires = copyUser(u), expires
+		c.lru.MoveToFront(el)
+		return
+	}
+	if c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tokenEntry).key)
+	}
+	c.entries[key] = c.lru.PushFront(&tokenEntry{key: key, user: copyUser(u), expires: expires})
+}
+
+// purge removes every entry from the cache.
+func (c *tokenCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	c.entries = make(map[[sha256.Size]byte]*list.Element)
+	c.gen++
+}
+
+func (c *tokenCache) stats() TokenCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return TokenCacheStats{Hits: c.hits, Misses: c.misses, Size: c.lru.Len()}
+}
+
+// copyUser returns a copy of u that shares no claims with it,
+// so that callers cannot modify cached users.
+func copyUser(u *User) User {
+	c := *u
+	if u.Claims != nil {
+		c.Claims = copyClaim(map[string]interface{}(u.Claims)).(map[string]interface{})
+	}
+	return c
+}
+
+// copyClaim returns a deep copy of a claim value decoded from JSON.
+func copyClaim(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			c[k] = copyClaim(e)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = copyClaim(e)
+		}
+		return c
+	}
+	return v
+}
+
+// sameKeys reports whether a and b hold the same keys.
+func sameKeys(a, b map[string]*rsa.PublicKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for kid, ka := range a {
+		if kb, ok := b[kid]; !ok || !ka.Equal(kb) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenCache(t *testing.T) {
+	const projectID = "projectID"
+	now := time.Now()
+	payload := func(sub string, exp time.Time) map[string]interface{} {
+		return map[string]interface{}{
+			"exp":     exp.Unix(),
+			"iat":     now.Add(-time.Minute).Unix(),
+			"aud":     projectID,
+			"iss":     "https://securetoken.google.com/" + projectID,
+			"sub":     sub,
+			"user_id": sub,
+			"firebase": map[string]interface{}{
+				"sign_in_provider": "password",
+			},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	clock := now
+	src := &rotatingKeySource{}
+	v := NewVerifier(ctx, projectID, nil, WithKeySource(src), WithTokenCache(2),
+		WithMinRefreshInterval(0), WithClock(func() time.Time { return clock }))
+	defer v.Close()
+
+	a := genToken(payload("a", now.Add(time.Hour)), validKeys[0])
+	for i := 0; i < 3; i++ {
+		if u, err := v.Verify(ctx, a); err != nil {
+			t.Fatalf("Verify: %v", err)
+		} else if u.ID != "a" {
+			t.Errorf("got user ID %q, want a", u.ID)
+		}
+	}
+	if st := v.TokenCacheStats(); st != (TokenCacheStats{Hits: 2, Misses: 1, Size: 1}) {
+		t.Errorf("got stats %+v after repeated tokens", st)
+	}
+
+	// Returned users can be modified without affecting the cache.
+	u, _ := v.Verify(ctx, a)
+	u.ID = "changed"
+	u.Claims["sub"] = "changed"
+	u.Claims.Get("firebase").(map[string]interface{})["sign_in_provider"] = "changed"
+	if u, _ := v.Verify(ctx, a); u.ID != "a" {
+		t.Errorf("got user ID %q from cache after modification", u.ID)
+	} else if sub := u.Claims.Get("sub"); sub != "a" {
+		t.Errorf("got sub claim %q from cache after modification", sub)
+	} else if p := u.Claims.Get("firebase").(map[string]interface{})["sign_in_provider"]; p != "password" {
+		t.Errorf("got sign_in_provider %q from cache after modification", p)
+	}
+
+	// Failures are not cached.
+	bad := genToken(payload("bad", now.Add(-time.Hour)), validKeys[0])
+	for i := 0; i < 2; i++ {
+		if _, err := v.Verify(ctx, bad); !errors.Is(err, ErrExpired) {
+			t.Errorf("expired token: got err %v, want ErrExpired", err)
+		}
+	}
+	if st := v.TokenCacheStats(); st.Size != 1 {
+		t.Errorf("got size %d after failures, want 1", st.Size)
+	}
+
+	// The least recently used token is evicted.
+	b := genToken(payload("b", now.Add(time.Hour)), validKeys[0])
+	c := genToken(payload("c", now.Add(time.Hour)), validKeys[0])
+	v.Verify(ctx, b)
+	v.Verify(ctx, a)
+	v.Verify(ctx, c) // evicts b
+	before := v.TokenCacheStats()
+	v.Verify(ctx, a)
+	v.Verify(ctx, b)
+	if st := v.TokenCacheStats(); st.Hits != before.Hits+1 || st.Misses != before.Misses+1 || st.Size != 2 {
+		t.Errorf("got stats %+v after eviction, started at %+v", st, before)
+	}
+
+	// Cached tokens expire.
+	clock = now.Add(2 * time.Hour)
+	if _, err := v.Verify(ctx, a); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired cached token: got err %v, want ErrExpired", err)
+	}
+	clock = now
+
+	// The cache is purged when the keys change.
+	v.Verify(ctx, a)
+	src.rotate()
+	if _, err := v.Verify(ctx, genToken(payload("new", now.Add(time.Hour)), validKeys[1])); err != nil {
+		t.Fatalf("token with new key: %v", err)
+	}
+	if _, err := v.Verify(ctx, a); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("cached token with old key: got err %v, want ErrUnknownKeyID", err)
+	}
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// identityToolkitURL is the base URL of the Identity Toolkit REST API.
+const identityToolkitURL = "https://identitytoolkit.googleapis.com/v1"
+
+// APIError is returned when the Identity Toolkit API responds
+// with an error.
+type APIError struct {
+	// StatusCode is the HTTP status code returned by the server.
+	StatusCode int
+
+	// Code is the error code reported by the server, such as
+	// "USER_NOT_FOUND" or "INVALID_ID_TOKEN". It is empty if
+	// the response could not be decoded.
+	Code string
+
+	// Message is the full error message returned by the server.
+	Message string
+}
+
+func (err *APIError) Error() string {
+	return fmt.Sprintf("auth: identity toolkit returned HTTP %d: %s", err.StatusCode, err.Message)
+}
+
+// Is reports whether target is ErrUserNotFound and the server
+// reported that the user does not exist.
+func (err *APIError) Is(target error) bool {
+	return target == ErrUserNotFound && err.Code == "USER_NOT_FOUND"
+}
+
+// ErrUserNotFound is returned when the requested user does not exist.
+// Errors returned by the server for a missing user match it with
+// errors.Is.
+var ErrUserNotFound = errors.New("auth: user not found")
+
+// toolkit makes authorized requests to the Identity Toolkit API
+// on behalf of a single project.
+type toolkit struct {
+	baseURL   string // identityToolkitURL, or a fake server in tests
+	projectID string
+	client    *http.Client
+}
+
+func newToolkit(projectID string, client *http.Client) *toolkit {
+	return &toolkit{baseURL: identityToolkitURL, projectID: projectID, client: client}
+}
+
+// post sends req as JSON to the given path, relative to the
+// project's resource (e.g. ":createSessionCookie" or "/accounts:lookup"),
+// and decodes the JSON response into resp, if non-nil.
+func (t *toolkit) post(ctx context.Context, path string, req, resp interface{}) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("auth: cannot marshal request: %v", err)
+	}
+	u := t.baseURL + "/projects/" + t.projectID + path
+	httpReq, err := http.NewRequest("POST", u, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("auth: could not create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return t.do(ctx, httpReq, resp)
+}
+
+// get sends a GET request with the given query parameters to the
+// given path, relative to the project's resource, and decodes the
+// JSON response into resp.
+func (t *toolkit) get(ctx context.Context, path string, query url.Values, resp interface{}) error {
+	u := t.baseURL + "/projects/" + t.projectID + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	httpReq, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return fmt.Errorf("auth: could not create request: %v", err)
+	}
+	return t.do(ctx, httpReq, resp)
+}
+
+func (t *toolkit) do(ctx context.Context, req *http.Request, resp interface{}) error {
+	httpResp, err := t.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(httpResp.Body)
+		return newAPIError(httpResp.StatusCode, body)
+	}
+	if resp == nil {
+		return nil
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return fmt.Errorf("auth: could not decode response: %v", err)
+	}
+	return nil
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Message: string(body)}
+	var parsed struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error.Message != "" {
+		// Messages look like "INVALID_ID_TOKEN" or
+		// "INVALID_ID_TOKEN : some further detail".
+		apiErr.Message = parsed.Error.Message
+		apiErr.Code = strings.TrimSpace(strings.SplitN(parsed.Error.Message, ":", 2)[0])
+	}
+	return apiErr
+}
+
+// isTransient reports whether err, returned by a toolkit request,
+// is likely to go away if the request is retried.
+func isTransient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// Errors from the HTTP client are transient, unless the
+	// request was cancelled; errors building the request or
+	// decoding the response are not.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,330 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserRecord is a user of a project, as returned by AdminClient.
+type UserRecord struct {
+	UID           string
+	Email         string
+	EmailVerified bool
+	PhoneNumber   string
+	DisplayName   string
+	PhotoURL      string
+	Disabled      bool
+
+	// TenantID is the Identity Platform tenant the user belongs to,
+	// or empty if the user does not belong to a tenant.
+	TenantID string
+
+	// ProviderData holds the user's information from each
+	// identity provider linked to the account.
+	ProviderData []ProviderInfo
+
+	// CustomClaims holds the custom claims set on the user,
+	// which are added to the user's ID tokens.
+	CustomClaims map[string]interface{}
+
+	// TokensValidAfter is the time before which the user's
+	// tokens are considered revoked.
+	TokensValidAfter time.Time
+
+	Metadata UserMetadata
+}
+
+// UserMetadata holds timestamps of a user's account.
+// Times are zero if the event never happened.
+type UserMetadata struct {
+	CreationTime    time.Time
+	LastSignInTime  time.Time
+	LastRefreshTime time.Time
+}
+
+// ProviderInfo is a user's information from an identity provider,
+// such as "google.com" or "password".
+type ProviderInfo struct {
+	ProviderID  string
+	UID         string // the user's ID at the provider
+	Email       string
+	PhoneNumber string
+	DisplayName string
+	PhotoURL    string
+}
+
+// userResponse is a user as returned by the accounts:lookup endpoint.
+type userResponse struct {
+	LocalID          string `json:"localId"`
+	Email            string `json:"email"`
+	EmailVerified    bool   `json:"emailVerified"`
+	PhoneNumber      string `json:"phoneNumber"`
+	DisplayName      string `json:"displayName"`
+	PhotoURL         string `json:"photoUrl"`
+	Disabled         bool   `json:"disabled"`
+	TenantID         string `json:"tenantId"`
+	CustomAttributes string `json:"customAttributes"`
+	ValidSince       string `json:"validSince"`    // seconds
+	CreatedAt        string `json:"createdAt"`     // milliseconds
+	LastLoginAt      string `json:"lastLoginAt"`   // milliseconds
+	LastRefreshAt    string `json:"lastRefreshAt"` // RFC 3339
+	ProviderUserInfo []struct {
+		ProviderID  string `json:"providerId"`
+		RawID       string `json:"rawId"`
+		Email       string `json:"email"`
+		PhoneNumber string `json:"phoneNumber"`
+		DisplayName string `json:"displayName"`
+		PhotoURL    string `json:"photoUrl"`
+	} `json:"providerUserInfo"`
+}
+
+func (r *userResponse) record() (*UserRecord, error) {
+	u := &UserRecord{
+		UID:           r.LocalID,
+		Email:         r.Email,
+		EmailVerified: r.EmailVerified,
+		PhoneNumber:   r.PhoneNumber,
+		DisplayName:   r.DisplayName,
+		PhotoURL:      r.PhotoURL,
+		Disabled:      r.Disabled,
+		TenantID:      r.TenantID,
+	}
+	for _, p := range r.ProviderUserInfo {
+		u.ProviderData = append(u.ProviderData, ProviderInfo{
+			ProviderID:  p.ProviderID,
+			UID:         p.RawID,
+			Email:       p.Email,
+			PhoneNumber: p.PhoneNumber,
+			DisplayName: p.DisplayName,
+			PhotoURL:    p.PhotoURL,
+		})
+	}
+	if r.CustomAttributes != "" {
+		if err := json.Unmarshal([]byte(r.CustomAttributes), &u.CustomClaims); err != nil {
+			return nil, fmt.Errorf("auth: could not parse custom claims of user %q: %v", r.LocalID, err)
+		}
+	}
+
+	var err error
+	if u.TokensValidAfter, err = parseUnixTime(r.ValidSince, time.Second); err != nil {
+		return nil, fmt.Errorf("auth: could not parse validSince %q: %v", r.ValidSince, err)
+	}
+	if u.Metadata.CreationTime, err = parseUnixTime(r.CreatedAt, time.Millisecond); err != nil {
+		return nil, fmt.Errorf("auth: could not parse createdAt %q: %v", r.CreatedAt, err)
+	}
+	if u.Metadata.LastSignInTime, err = parseUnixTime(r.LastLoginAt, time.Millisecond); err != nil {
+		return nil, fmt.Errorf("auth: could not parse lastLoginAt %q: %v", r.LastLoginAt, err)
+	}
+	if r.LastRefreshAt != "" {
+		if u.Metadata.LastRefreshTime, err = time.Parse(time.RFC3339, r.LastRefreshAt); err != nil {
+			return nil, fmt.Errorf("auth: could not parse lastRefreshAt %q: %v", r.LastRefreshAt, err)
+		}
+	}
+	return u, nil
+}
+
+// parseUnixTime parses s, a decimal number of units since the
+// Unix epoch. It returns the zero time if s is empty.
+func parseUnixTime(s string, unit time.Duration) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, n*int64(unit)), nil
+}
+
+// UserToCreate holds the properties of a user to create with
+// AdminClient.CreateUser. Its setters return it, so that calls can
+// be chained:
+//
+//	user := (&auth.UserToCreate{}).Email("alice@example.com").Password("secret123")
+type UserToCreate struct {
+	params map[string]interface{}
+}
+
+func (u *UserToCreate) set(key string, value interface{}) *UserToCreate {
+	if u.params == nil {
+		u.params = make(map[string]interface{})
+	}
+	u.params[key] = value
+	return u
+}
+
+// UID sets the user ID. If it is not set, the server generates one.
+func (u *UserToCreate) UID(uid string) *UserToCreate { return u.set("localId", uid) }
+
+// Email sets the user's email address.
+func (u *UserToCreate) Email(email string) *UserToCreate { return u.set("email", email) }
+
+// EmailVerified sets whether the user's email address is verified.
+func (u *UserToCreate) EmailVerified(verified bool) *UserToCreate {
+	return u.set("emailVerified", verified)
+}
+
+// PhoneNumber sets the user's phone number, in E.164 format.
+func (u *UserToCreate) PhoneNumber(phone string) *UserToCreate { return u.set("phoneNumber", phone) }
+
+// Password sets the user's password, which must be at least
+// 6 characters long.
+func (u *UserToCreate) Password(password string) *UserToCreate { return u.set("password", password) }
+
+// DisplayName sets the user's display name.
+func (u *UserToCreate) DisplayName(name string) *UserToCreate { return u.set("displayName", name) }
+
+// PhotoURL sets the URL of the user's photo.
+func (u *UserToCreate) PhotoURL(url string) *UserToCreate { return u.set("photoUrl", url) }
+
+// Disabled sets whether the user is disabled.
+func (u *UserToCreate) Disabled(disabled bool) *UserToCreate { return u.set("disabled", disabled) }
+
+func (u *UserToCreate) validate() error {
+	if uid, ok := u.params["localId"].(string); ok {
+		if err := validateUID(uid); err != nil {
+			return err
+		}
+	}
+	return validateUserParams(u.params)
+}
+
+// UserToUpdate holds the properties of a user to change with
+// AdminClient.UpdateUser. Only the properties that are set are
+// changed. Its setters return it, so that calls can be chained:
+//
+//	update := (&auth.UserToUpdate{}).DisplayName("Alice").Disabled(false)
+type UserToUpdate struct {
+	params map[string]interface{}
+}
+
+func (u *UserToUpdate) set(key string, value interface{}) *UserToUpdate {
+	if u.params == nil {
+		u.params = make(map[string]interface{})
+	}
+	u.params[key] = value
+	return u
+}
+
+// appendParam appends value to the list parameter key.
+func (u *UserToUpdate) appendParam(key, value string) *UserToUpdate {
+	list, _ := u.params[key].([]string)
+	for _, v := range list {
+		if v == value {
+			return u
+		}
+	}
+	return u.set(key, append(list, value))
+}
+
+// removeParam removes value from the list parameter key.
+func (u *UserToUpdate) removeParam(key, value string) {
+	list, _ := u.params[key].([]string)
+	var kept []string
+	for _, v := range list {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) == 0 {
+		delete(u.params, key)
+	} else {
+		u.params[key] = kept
+	}
+}
+
+// Email sets the user's email address.
+func (u *UserToUpdate) Email(email string) *UserToUpdate { return u.set("email", email) }
+
+// EmailVerified sets whether the user's email address is verified.
+func (u *UserToUpdate) EmailVerified(verified bool) *UserToUpdate {
+	return u.set("emailVerified", verified)
+}
+
+// PhoneNumber sets the user's phone number, in E.164 format.
+// An empty phone number removes it, and unlinks the phone provider.
+func (u *UserToUpdate) PhoneNumber(phone string) *UserToUpdate {
+	if phone == "" {
+		delete(u.params, "phoneNumber")
+		return u.appendParam("deleteProvider", "phone")
+	}
+	u.removeParam("deleteProvider", "phone")
+	return u.set("phoneNumber", phone)
+}
+
+// Password sets the user's password, which must be at least
+// 6 characters long.
+func (u *UserToUpdate) Password(password string) *UserToUpdate { return u.set("password", password) }
+
+// DisplayName sets the user's display name. An empty name removes it.
+func (u *UserToUpdate) DisplayName(name string) *UserToUpdate {
+	return u.setOrDelete("displayName", "DISPLAY_NAME", name)
+}
+
+// PhotoURL sets the URL of the user's photo. An empty URL removes it.
+func (u *UserToUpdate) PhotoURL(url string) *UserToUpdate {
+	return u.setOrDelete("photoUrl", "PHOTO_URL", url)
+}
+
+// Disabled sets whether the user is disabled.
+func (u *UserToUpdate) Disabled(disabled bool) *UserToUpdate { return u.set("disableUser", disabled) }
+
+// CustomClaims sets the user's custom claims, replacing any previous
+// ones, as with AdminClient.SetCustomUserClaims. A nil or empty map
+// removes them.
+func (u *UserToUpdate) CustomClaims(claims map[string]interface{}) *UserToUpdate {
+	if claims == nil {
+		claims = map[string]interface{}{}
+	}
+	return u.set("customAttributes", claims)
+}
+
+// setOrDelete sets key to value, or deletes the attribute
+// if value is empty.
+func (u *UserToUpdate) setOrDelete(key, attribute, value string) *UserToUpdate {
+	if value == "" {
+		delete(u.params, key)
+		return u.appendParam("deleteAttribute", attribute)
+	}
+	u.removeParam("deleteAttribute", attribute)
+	return u.set(key, value)
+}
+
+// request validates the update and returns the
+// accounts:update request for the user uid.
+func (u *UserToUpdate) request(uid string) (map[string]interface{}, error) {
+	if err := validateUserParams(u.params); err != nil {
+		return nil, err
+	}
+	req := make(map[string]interface{}, len(u.params)+1)
+	for k, v := range u.params {
+		req[k] = v
+	}
+	req["localId"] = uid
+	if claims, ok := u.params["customAttributes"].(map[string]interface{}); ok {
+		attrs, err := marshalCustomClaims(claims)
+		if err != nil {
+			return nil, err
+		}
+		req["customAttributes"] = attrs
+	}
+	return req, nil
+}
+
+// validateUserParams checks the properties common to
+// UserToCreate and UserToUpdate.
+func validateUserParams(params map[string]interface{}) error {
+	if email, ok := params["email"].(string); ok && !strings.Contains(email, "@") {
+		return fmt.Errorf("auth: invalid email %q", email)
+	}
+	if phone, ok := params["phoneNumber"].(string); ok && !strings.HasPrefix(phone, "+") {
+		return fmt.Errorf("auth: phone number %q is not in E.164 format", phone)
+	}
+	if password, ok := params["password"].(string); ok && len(password) < minPasswordLength {
+		return fmt.Errorf("auth: password must be at least %d characters long", minPasswordLength)
+	}
+	return nil
+}
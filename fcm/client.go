@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -15,19 +16,65 @@ import (
 const apiURL = "https://fcm.googleapis.com/fcm/send"
 
 type Client struct {
-	apiKey string
-	apiURL string
-	client *http.Client
+	apiKey  string
+	apiURL  string
+	client  *http.Client
+	logger  *slog.Logger
+	metrics Metrics
 }
 
-func NewClient(apiKey string, client *http.Client) *Client {
+// Metrics records a Client's activity, for export to a monitoring
+// system. See the metrics/prommetrics and metrics/otelmetrics packages
+// for implementations backed by Prometheus and OpenTelemetry.
+//
+// Methods may be called concurrently and should not block.
+type Metrics interface {
+	// SendDone records a call to Send that took d. The status is the
+	// HTTP status code of the response, or 0 if there was none.
+	SendDone(status int, d time.Duration)
+
+	// MessageDone records the result of delivering a message to a
+	// single recipient. The error code is one of the values of
+	// MessageResult.Error, which is empty on success.
+	MessageDone(errorCode string)
+}
+
+// A ClientOption configures a Client. Options are passed to NewClient.
+type ClientOption func(*Client)
+
+// WithMetrics sets a Metrics to record the Client's activity.
+func WithMetrics(m Metrics) ClientOption {
+	if m == nil {
+		panic("fcm: nil metrics")
+	}
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// WithLogger sets the logger that receives the Client's events, such as
+// failed sends. The default is slog.Default(), as in the auth package.
+func WithLogger(logger *slog.Logger) ClientOption {
+	if logger == nil {
+		panic("fcm: nil logger")
+	}
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+func NewClient(apiKey string, client *http.Client, opts ...ClientOption) *Client {
 	if apiKey == "" {
 		panic("fcm: empty apiKey")
 	}
 	if client == nil {
 		client = http.DefaultClient
 	}
-	return &Client{apiKey: apiKey, apiURL: apiURL, client: client}
+	c := &Client{apiKey: apiKey, apiURL: apiURL, client: client, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // ErrAuthenticationFailure is returned by Client.Send if the FCM server
@@ -38,10 +85,28 @@ func (c *Client) Send(ctx context.Context, msg *Message) (*Response, error) {
 	if msg == nil {
 		panic("fcm: cannot send nil msg")
 	}
+	if c.metrics == nil {
+		resp, _, err := c.send(ctx, msg)
+		return resp, err
+	}
 
+	start := time.Now()
+	resp, status, err := c.send(ctx, msg)
+	c.metrics.SendDone(status, time.Since(start))
+	if resp != nil {
+		for _, result := range resp.Results {
+			c.metrics.MessageDone(result.Error)
+		}
+	}
+	return resp, err
+}
+
+// send sends msg and additionally returns the HTTP status code
+// of the response, or 0 if there was none.
+func (c *Client) send(ctx context.Context, msg *Message) (*Response, int, error) {
 	data, err := json.Marshal(msg)
 	if err != nil {
-		return nil, fmt.Errorf("fcm: cannot marshal msg: %v", msg)
+		return nil, 0, fmt.Errorf("fcm: cannot marshal msg: %v", msg)
 	}
 	req, err := http.NewRequest("POST", c.apiURL, bytes.NewReader(data))
 	if err != nil {
@@ -52,7 +117,8 @@ func (c *Client) Send(ctx context.Context, msg *Message) (*Response, error) {
 	req.Header.Set("Content-Type", "application/json")
 	resp, err := c.client.Do(req.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		c.logger.WarnContext(ctx, "fcm: send failed", "err", err)
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
@@ -63,7 +129,8 @@ func (c *Client) Send(ctx context.Context, msg *Message) (*Response, error) {
 	// Handle 5xx outside of the switch since it is a large range.
 	if 500 <= resp.StatusCode && resp.StatusCode < 600 {
 		body, _ := ioutil.ReadAll(resp.Body)
-		return nil, &ServerError{
+		c.logger.WarnContext(ctx, "fcm: server error", "status", resp.StatusCode, "retry_after", retryAfter)
+		return nil, resp.StatusCode, &ServerError{
 			RetryAfter: retryAfter,
 			StatusCode: resp.StatusCode,
 			Body:       string(body),
@@ -73,21 +140,29 @@ func (c *Client) Send(ctx context.Context, msg *Message) (*Response, error) {
 	switch resp.StatusCode {
 	case http.StatusBadRequest:
 		body, _ := ioutil.ReadAll(resp.Body)
-		return nil, fmt.Errorf("fcm: invalid request: %s", body)
+		c.logger.WarnContext(ctx, "fcm: invalid request", "status", resp.StatusCode, "body", string(body))
+		return nil, resp.StatusCode, fmt.Errorf("fcm: invalid request: %s", body)
 
 	case http.StatusUnauthorized:
-		return nil, ErrAuthenticationFailure
+		c.logger.ErrorContext(ctx, "fcm: authentication failure", "status", resp.StatusCode)
+		return nil, resp.StatusCode, ErrAuthenticationFailure
 
 	case http.StatusOK:
 		var response Response
 		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-			return nil, fmt.Errorf("fcm: could not decode response: " + err.Error())
+			c.logger.WarnContext(ctx, "fcm: could not decode response", "err", err)
+			return nil, resp.StatusCode, fmt.Errorf("fcm: could not decode response: %v", err)
 		}
 		response.RetryAfter = retryAfter
-		return &response, nil
+		if response.Failure > 0 {
+			c.logger.DebugContext(ctx, "fcm: some messages failed",
+				"success", response.Success, "failure", response.Failure, "multicast_id", response.MulticastID)
+		}
+		return &response, resp.StatusCode, nil
 
 	default:
-		return nil, fmt.Errorf("fcm: unexpected status code %d", resp.StatusCode)
+		c.logger.WarnContext(ctx, "fcm: unexpected status code", "status", resp.StatusCode)
+		return nil, resp.StatusCode, fmt.Errorf("fcm: unexpected status code %d", resp.StatusCode)
 	}
 }
 
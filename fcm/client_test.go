@@ -0,0 +1,71 @@
+package fcm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeMetrics struct {
+	mu       sync.Mutex
+	sends    []int
+	messages map[string]int
+}
+
+func (m *fakeMetrics) SendDone(status int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sends = append(m.sends, status)
+}
+
+func (m *fakeMetrics) MessageDone(errorCode string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[errorCode]++
+}
+
+func TestMetrics(t *testing.T) {
+	var status int
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"multicast_id": 1, "success": 2, "failure": 1, "results": [
+				{"message_id": "1"}, {"message_id": "2"}, {"error": "NotRegistered"}]}`))
+		}
+	}))
+	defer serv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	m := &fakeMetrics{messages: make(map[string]int)}
+	c := NewClient("apiKey", nil, WithMetrics(m))
+	c.apiURL = serv.URL
+	msg := &Message{RegistrationIDs: []string{"a", "b", "c"}}
+
+	status = http.StatusOK
+	if _, err := c.Send(ctx, msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if _, err := c.Send(ctx, msg); err == nil {
+		t.Error("Send with server error: got nil error")
+	}
+	c.apiURL = "http://127.0.0.1:0"
+	if _, err := c.Send(ctx, msg); err == nil {
+		t.Error("Send to unreachable server: got nil error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if want := []int{200, 503, 0}; !reflect.DeepEqual(m.sends, want) {
+		t.Errorf("got sends %v, want %v", m.sends, want)
+	}
+	if want := map[string]int{"": 2, "NotRegistered": 1}; !reflect.DeepEqual(m.messages, want) {
+		t.Errorf("got messages %v, want %v", m.messages, want)
+	}
+}
@@ -0,0 +1,137 @@
+// Package otelmetrics exports the activity of auth.Verifier and
+// fcm.Client as OpenTelemetry metrics.
+//
+// Create a Metrics with New and pass it to auth.WithMetrics
+// and fcm.WithMetrics:
+//
+//	m, err := otelmetrics.New(otel.Meter("myapp"))
+//	if err != nil {
+//		// handle error
+//	}
+//	v := auth.NewVerifier(ctx, projectID, nil, auth.WithMetrics(m))
+//	c := fcm.NewClient(apiKey, nil, fcm.WithMetrics(m))
+package otelmetrics
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cmelbye/firebase-go/auth"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Metrics implements auth.Metrics and fcm.Metrics.
+type Metrics struct {
+	verifies      metric.Int64Counter
+	verifyLatency metric.Float64Histogram
+	refreshes     metric.Int64Counter
+	keys          atomic.Int64
+	keysFetched   atomic.Int64 // UnixNano of the last successful refresh
+
+	sends       metric.Int64Counter
+	sendLatency metric.Float64Histogram
+	messages    metric.Int64Counter
+}
+
+// New creates the instruments with meter.
+func New(meter metric.Meter) (*Metrics, error) {
+	m := new(Metrics)
+	var err error
+	if m.verifies, err = meter.Int64Counter("firebase.auth.verify",
+		metric.WithDescription("Number of tokens verified, by result.")); err != nil {
+		return nil, err
+	}
+	if m.verifyLatency, err = meter.Float64Histogram("firebase.auth.verify.duration",
+		metric.WithDescription("Time taken to verify a token."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if m.refreshes, err = meter.Int64Counter("firebase.auth.key_refresh",
+		metric.WithDescription("Number of attempts to fetch the public keys, by result.")); err != nil {
+		return nil, err
+	}
+	keys, err := meter.Int64ObservableGauge("firebase.auth.keys",
+		metric.WithDescription("Number of public keys currently in use."))
+	if err != nil {
+		return nil, err
+	}
+	keyAge, err := meter.Float64ObservableGauge("firebase.auth.key_age",
+		metric.WithDescription("Time since the public keys were last fetched."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		fetched := m.keysFetched.Load()
+		if fetched == 0 {
+			return nil // no keys yet
+		}
+		o.ObserveInt64(keys, m.keys.Load())
+		o.ObserveFloat64(keyAge, time.Since(time.Unix(0, fetched)).Seconds())
+		return nil
+	}, keys, keyAge); err != nil {
+		return nil, err
+	}
+
+	if m.sends, err = meter.Int64Counter("firebase.fcm.send",
+		metric.WithDescription("Number of send requests, by HTTP status code (0 if the request failed).")); err != nil {
+		return nil, err
+	}
+	if m.sendLatency, err = meter.Float64Histogram("firebase.fcm.send.duration",
+		metric.WithDescription("Time taken by send requests."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if m.messages, err = meter.Int64Counter("firebase.fcm.messages",
+		metric.WithDescription("Number of messages sent to individual recipients, by result.")); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VerifyDone implements auth.Metrics.
+func (m *Metrics) VerifyDone(reason auth.Reason, d time.Duration) {
+	ctx := context.Background()
+	attrs := metric.WithAttributes(attribute.String("result", reasonLabel(reason)))
+	m.verifies.Add(ctx, 1, attrs)
+	m.verifyLatency.Record(ctx, d.Seconds(), attrs)
+}
+
+// RefreshDone implements auth.Metrics.
+func (m *Metrics) RefreshDone(err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.refreshes.Add(context.Background(), 1, metric.WithAttributes(attribute.String("result", result)))
+}
+
+// KeysUpdated implements auth.Metrics.
+func (m *Metrics) KeysUpdated(count int, fetched time.Time) {
+	m.keys.Store(int64(count))
+	m.keysFetched.Store(fetched.UnixNano())
+}
+
+// SendDone implements fcm.Metrics.
+func (m *Metrics) SendDone(status int, d time.Duration) {
+	ctx := context.Background()
+	attrs := metric.WithAttributes(attribute.Int("status", status))
+	m.sends.Add(ctx, 1, attrs)
+	m.sendLatency.Record(ctx, d.Seconds(), attrs)
+}
+
+// MessageDone implements fcm.Metrics.
+func (m *Metrics) MessageDone(errorCode string) {
+	if errorCode == "" {
+		errorCode = "ok"
+	}
+	m.messages.Add(context.Background(), 1, metric.WithAttributes(attribute.String("result", errorCode)))
+}
+
+// reasonLabel returns the attribute value for a verification result.
+func reasonLabel(reason auth.Reason) string {
+	if reason == 0 {
+		return "ok"
+	}
+	return strings.Replace(reason.String(), " ", "_", -1)
+}
@@ -0,0 +1,87 @@
+package otelmetrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cmelbye/firebase-go/auth"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	m, err := New(provider.Meter("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.VerifyDone(0, time.Millisecond)
+	m.VerifyDone(auth.ReasonExpired, time.Millisecond)
+	m.VerifyDone(auth.ReasonExpired, time.Millisecond)
+	m.RefreshDone(nil)
+	m.KeysUpdated(3, time.Now())
+	m.SendDone(200, time.Second)
+	m.MessageDone("")
+	m.MessageDone("NotRegistered")
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, metric := range sm.Metrics {
+			got[metric.Name] = metric.Data
+		}
+	}
+
+	// sum returns the value of the int64 counter name
+	// for the data point with the attribute key=value.
+	sum := func(name, key string, value attribute.Value) int64 {
+		data, ok := got[name].(metricdata.Sum[int64])
+		if !ok {
+			t.Errorf("%s: got %T, want an int64 sum", name, got[name])
+			return 0
+		}
+		for _, dp := range data.DataPoints {
+			if v, ok := dp.Attributes.Value(attribute.Key(key)); ok && v == value {
+				return dp.Value
+			}
+		}
+		return 0
+	}
+	if n := sum("firebase.auth.verify", "result", attribute.StringValue("ok")); n != 1 {
+		t.Errorf("verify ok: got %d, want 1", n)
+	}
+	if n := sum("firebase.auth.verify", "result", attribute.StringValue(reasonLabel(auth.ReasonExpired))); n != 2 {
+		t.Errorf("verify expired: got %d, want 2", n)
+	}
+	if n := sum("firebase.auth.key_refresh", "result", attribute.StringValue("ok")); n != 1 {
+		t.Errorf("key refresh ok: got %d, want 1", n)
+	}
+	if n := sum("firebase.fcm.send", "status", attribute.IntValue(200)); n != 1 {
+		t.Errorf("send 200: got %d, want 1", n)
+	}
+	if n := sum("firebase.fcm.messages", "result", attribute.StringValue("NotRegistered")); n != 1 {
+		t.Errorf("messages NotRegistered: got %d, want 1", n)
+	}
+
+	if keys, ok := got["firebase.auth.keys"].(metricdata.Gauge[int64]); !ok || len(keys.DataPoints) != 1 || keys.DataPoints[0].Value != 3 {
+		t.Errorf("keys: got %+v, want 3", got["firebase.auth.keys"])
+	}
+	if latency, ok := got["firebase.auth.verify.duration"].(metricdata.Histogram[float64]); !ok {
+		t.Errorf("verify duration: got %T, want a float64 histogram", got["firebase.auth.verify.duration"])
+	} else {
+		var count uint64
+		for _, dp := range latency.DataPoints {
+			count += dp.Count
+		}
+		if count != 3 {
+			t.Errorf("verify duration: got %d observations, want 3", count)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Package prommetrics exports the activity of auth.Verifier and
+// fcm.Client as Prometheus metrics.
+//
+// Create a Metrics with New and pass it to auth.WithMetrics
+// and fcm.WithMetrics:
+//
+//	m, err := prommetrics.New(prometheus.DefaultRegisterer, "myapp")
+//	if err != nil {
+//		// handle error
+//	}
+//	v := auth.NewVerifier(ctx, projectID, nil, auth.WithMetrics(m))
+//	c := fcm.NewClient(apiKey, nil, fcm.WithMetrics(m))
+package prommetrics
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cmelbye/firebase-go/auth"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements auth.Metrics and fcm.Metrics.
+type Metrics struct {
+	verifies      *prometheus.CounterVec
+	verifyLatency prometheus.Histogram
+	refreshes     *prometheus.CounterVec
+	keys          prometheus.Gauge
+	keysFetched   atomic.Int64 // UnixNano of the last successful refresh
+
+	sends       *prometheus.CounterVec
+	sendLatency prometheus.Histogram
+	messages    *prometheus.CounterVec
+}
+
+// New creates the metrics, prefixed with namespace, and registers
+// them with reg.
+func New(reg prometheus.Registerer, namespace string) (*Metrics, error) {
+	m := &Metrics{
+		verifies: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "verify_total",
+			Help:      "Number of tokens verified, by result.",
+		}, []string{"result"}),
+		verifyLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "verify_duration_seconds",
+			Help:      "Time taken to verify a token.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 8),
+		}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "key_refresh_total",
+			Help:      "Number of attempts to fetch the public keys, by result.",
+		}, []string{"result"}),
+		keys: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "keys",
+			Help:      "Number of public keys currently in use.",
+		}),
+		sends: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "fcm",
+			Name:      "send_total",
+			Help:      "Number of send requests, by HTTP status code (0 if the request failed).",
+		}, []string{"status"}),
+		sendLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "fcm",
+			Name:      "send_duration_seconds",
+			Help:      "Time taken by send requests.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "fcm",
+			Name:      "messages_total",
+			Help:      "Number of messages sent to individual recipients, by result.",
+		}, []string{"result"}),
+	}
+	keyAge := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "key_age_seconds",
+		Help:      "Time since the public keys were last fetched, or 0 if they never were.",
+	}, func() float64 {
+		fetched := m.keysFetched.Load()
+		if fetched == 0 {
+			return 0
+		}
+		return time.Since(time.Unix(0, fetched)).Seconds()
+	})
+
+	for _, c := range []prometheus.Collector{
+		m.verifies, m.verifyLatency, m.refreshes, m.keys, keyAge,
+		m.sends, m.sendLatency, m.messages,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// VerifyDone implements auth.Metrics.
+func (m *Metrics) VerifyDone(reason auth.Reason, d time.Duration) {
+	m.verifies.WithLabelValues(reasonLabel(reason)).Inc()
+	m.verifyLatency.Observe(d.Seconds())
+}
+
+// RefreshDone implements auth.Metrics.
+func (m *Metrics) RefreshDone(err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.refreshes.WithLabelValues(result).Inc()
+}
+
+// KeysUpdated implements auth.Metrics.
+func (m *Metrics) KeysUpdated(count int, fetched time.Time) {
+	m.keys.Set(float64(count))
+	m.keysFetched.Store(fetched.UnixNano())
+}
+
+// SendDone implements fcm.Metrics.
+func (m *Metrics) SendDone(status int, d time.Duration) {
+	m.sends.WithLabelValues(strconv.Itoa(status)).Inc()
+	m.sendLatency.Observe(d.Seconds())
+}
+
+// MessageDone implements fcm.Metrics.
+func (m *Metrics) MessageDone(errorCode string) {
+	if errorCode == "" {
+		errorCode = "ok"
+	}
+	m.messages.WithLabelValues(errorCode).Inc()
+}
+
+// reasonLabel returns the label value for a verification result.
+func reasonLabel(reason auth.Reason) string {
+	if reason == 0 {
+		return "ok"
+	}
+	return strings.Replace(reason.String(), " ", "_", -1)
+}
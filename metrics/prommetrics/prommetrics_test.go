@@ -0,0 +1,47 @@
+package prommetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cmelbye/firebase-go/auth"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := New(reg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.VerifyDone(0, time.Millisecond)
+	m.VerifyDone(auth.ReasonExpired, time.Millisecond)
+	m.VerifyDone(auth.ReasonExpired, time.Millisecond)
+	m.KeysUpdated(3, time.Now())
+	m.SendDone(200, time.Second)
+	m.MessageDone("")
+	m.MessageDone("NotRegistered")
+
+	if got := testutil.ToFloat64(m.verifies.WithLabelValues("ok")); got != 1 {
+		t.Errorf("verify ok: got %v, want 1", got)
+	}
+	expired := reasonLabel(auth.ReasonExpired)
+	if got := testutil.ToFloat64(m.verifies.WithLabelValues(expired)); got != 2 {
+		t.Errorf("verify %s: got %v, want 2", expired, got)
+	}
+	if got := testutil.ToFloat64(m.keys); got != 3 {
+		t.Errorf("keys: got %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(m.sends.WithLabelValues("200")); got != 1 {
+		t.Errorf("send 200: got %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.messages.WithLabelValues("NotRegistered")); got != 1 {
+		t.Errorf("messages NotRegistered: got %v, want 1", got)
+	}
+
+	if _, err := New(reg, "test"); err == nil {
+		t.Error("registering twice: got nil error")
+	}
+}